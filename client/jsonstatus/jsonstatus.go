@@ -0,0 +1,58 @@
+// Package jsonstatus fetches a custom JSON status page of nginx, e.g. published with njs.
+package jsonstatus
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// NginxClient allows you to fetch a JSON status page of an arbitrary structure.
+type NginxClient struct {
+	apiEndpoint string
+	httpClient  *http.Client
+}
+
+// NewNginxClient creates an NginxClient.
+func NewNginxClient(httpClient *http.Client, apiEndpoint string) *NginxClient {
+	return &NginxClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+}
+
+// GetStatus fetches the status page. The objects are decoded into maps, the arrays into slices and the numbers
+// into json.Number. The request is canceled when ctx is done.
+func (client *NginxClient) GetStatus(ctx context.Context) (interface{}, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.apiEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", client.apiEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	var status interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&status); err != nil {
+		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), &nginxclient.ParseError{Err: err})
+	}
+	return status, nil
+}
@@ -0,0 +1,32 @@
+package jsonstatus
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"requests": 9007199254740993, "zones": [{"name": "main"}]}`))
+	}))
+	defer server.Close()
+
+	status, err := NewNginxClient(server.Client(), server.URL).GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+
+	expected := map[string]interface{}{
+		"requests": json.Number("9007199254740993"),
+		"zones":    []interface{}{map[string]interface{}{"name": "main"}},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("GetStatus() = %#v, want %#v", status, expected)
+	}
+}
@@ -14,6 +14,28 @@ server accepts handled requests
 Reading: %d Writing: %d Waiting: %d
 `
 
+// StatusError is returned by the clients when the server responds with an unexpected status.
+type StatusError struct {
+	StatusCode int
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("expected %v response, got %v", http.StatusOK, e.StatusCode)
+}
+
+// ParseError is returned by the clients when the response body cannot be parsed.
+type ParseError struct {
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
 // NginxClient allows you to fetch NGINX metrics from the stub_status page.
 type NginxClient struct {
 	apiEndpoint string
@@ -63,7 +85,7 @@ func (client *NginxClient) GetStubStats() (*StubStats, error) {
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode)
+		return nil, &StatusError{StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -74,7 +96,7 @@ func (client *NginxClient) GetStubStats() (*StubStats, error) {
 	r := bytes.NewReader(body)
 	stats, err := parseStubStats(r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), err)
+		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), &ParseError{Err: err})
 	}
 
 	return stats, nil
@@ -0,0 +1,297 @@
+// Package plus fetches the NGINX Plus API endpoints and fields that are not supported by the nginx-plus-go-client, and
+// the large endpoints that it reads into memory before decoding them.
+package plus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// isNotFound reports whether the requested endpoint does not exist, e.g. /license of the versions before R33.
+func isNotFound(err error) bool {
+	var statusErr *nginxclient.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
+// NginxClient allows you to fetch NGINX Plus metrics from the API.
+type NginxClient struct {
+	apiEndpoint string
+	apiVersion  int
+	httpClient  *http.Client
+
+	// the number of the peers of every upstream in the last response, to allocate the peers of the next one; the maps
+	// are replaced, not changed, so they can be read without the mutex
+	upstreamPeers       map[string]int
+	streamUpstreamPeers map[string]int
+	mutex               sync.Mutex
+}
+
+// SSL represents the SSL failure reasons, available since API version 8 (NGINX Plus R27).
+// The fields are nil for the NGINX Plus versions that do not report them.
+type SSL struct {
+	NoCommonProtocol *uint64            `json:"no_common_protocol"`
+	NoCommonCipher   *uint64            `json:"no_common_cipher"`
+	HandshakeTimeout *uint64            `json:"handshake_timeout"`
+	PeerRejectedCert *uint64            `json:"peer_rejected_cert"`
+	VerifyFailures   *SSLVerifyFailures `json:"verify_failures"`
+}
+
+// SSLVerifyFailures represents the failures of SSL certificate verifications by reason.
+type SSLVerifyFailures struct {
+	NoCert           uint64 `json:"no_cert"`
+	ExpiredCert      uint64 `json:"expired_cert"`
+	RevokedCert      uint64 `json:"revoked_cert"`
+	HostnameMismatch uint64 `json:"hostname_mismatch"`
+	Other            uint64 `json:"other"`
+}
+
+// License represents the license and the usage reporting state, available since NGINX Plus R33.
+type License struct {
+	// ActiveTill is the Unix time when the license expires.
+	ActiveTill int64 `json:"active_till"`
+	Eval       bool  `json:"eval"`
+	Reporting  struct {
+		Healthy bool  `json:"healthy"`
+		Fails   int64 `json:"fails"`
+		// Grace is the remaining grace period of the usage reporting in seconds.
+		Grace int64 `json:"grace"`
+	} `json:"reporting"`
+}
+
+// NewNginxClient creates an NginxClient for the API endpoint, e.g. http://127.0.0.1:8080/api, and the API version.
+func NewNginxClient(httpClient *http.Client, apiEndpoint string, apiVersion int) *NginxClient {
+	return &NginxClient{
+		apiEndpoint: strings.TrimSuffix(apiEndpoint, "/"),
+		apiVersion:  apiVersion,
+		httpClient:  httpClient,
+	}
+}
+
+// GetAPIVersions fetches the API versions supported by NGINX Plus from the API endpoint.
+func GetAPIVersions(ctx context.Context, httpClient *http.Client, apiEndpoint string) ([]int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", apiEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	var versions []int
+	if err := json.NewDecoder(resp.Body).Decode(&versions); err != nil {
+		return nil, fmt.Errorf("failed to parse response body: %w", &nginxclient.ParseError{Err: err})
+	}
+	return versions, nil
+}
+
+// GetSSL fetches the SSL failure reasons.
+func (client *NginxClient) GetSSL(ctx context.Context) (*SSL, error) {
+	var ssl SSL
+	if err := client.get(ctx, "ssl", &ssl); err != nil {
+		return nil, fmt.Errorf("failed to get ssl: %w", err)
+	}
+	return &ssl, nil
+}
+
+// GetLicense fetches the license. It returns nil if NGINX Plus does not report the license.
+func (client *NginxClient) GetLicense(ctx context.Context) (*License, error) {
+	var license License
+	if err := client.get(ctx, "license", &license); err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get license: %w", err)
+	}
+	return &license, nil
+}
+
+// GetUpstreams fetches the HTTP upstreams. The response is decoded while it is read, see decodeUpstreams.
+func (client *NginxClient) GetUpstreams(ctx context.Context) (*plusclient.Upstreams, error) {
+	client.mutex.Lock()
+	peers := client.upstreamPeers
+	client.mutex.Unlock()
+
+	var upstreams plusclient.Upstreams
+	err := client.decode(ctx, "http/upstreams", func(dec *json.Decoder) error {
+		var err error
+		upstreams, peers, err = decodeUpstreams(dec, peers, func(upstream *plusclient.Upstream, peers []plusclient.Peer) {
+			upstream.Peers = peers
+		})
+		return err
+	})
+	if err == nil {
+		client.mutex.Lock()
+		client.upstreamPeers = peers
+		client.mutex.Unlock()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstreams: %w", err)
+	}
+	return &upstreams, nil
+}
+
+// GetStreamUpstreams fetches the stream upstreams. The upstreams are empty if NGINX Plus has no stream block.
+func (client *NginxClient) GetStreamUpstreams(ctx context.Context) (*plusclient.StreamUpstreams, error) {
+	client.mutex.Lock()
+	peers := client.streamUpstreamPeers
+	client.mutex.Unlock()
+
+	var upstreams plusclient.StreamUpstreams
+	err := client.decode(ctx, "stream/upstreams", func(dec *json.Decoder) error {
+		var err error
+		upstreams, peers, err = decodeUpstreams(dec, peers, func(upstream *plusclient.StreamUpstream, peers []plusclient.StreamPeer) {
+			upstream.Peers = peers
+		})
+		return err
+	})
+	if err == nil {
+		client.mutex.Lock()
+		client.streamUpstreamPeers = peers
+		client.mutex.Unlock()
+	}
+	if err != nil {
+		if isNotFound(err) {
+			return &plusclient.StreamUpstreams{}, nil
+		}
+		return nil, fmt.Errorf("failed to get stream upstreams: %w", err)
+	}
+	return &upstreams, nil
+}
+
+// decodeUpstreams decodes the upstreams by their names without buffering the whole response. The peers of every
+// upstream are decoded one by one into a slice allocated for the number of the peers of the upstream in the previous
+// response, peers, the other fields of the upstream are small and decoded into U as usual. It returns the upstreams
+// and the number of the peers of every upstream.
+func decodeUpstreams[U any, P any](dec *json.Decoder, peers map[string]int, setPeers func(upstream *U, peers []P)) (map[string]U, map[string]int, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return nil, nil, err
+	}
+	upstreams := make(map[string]U, len(peers))
+	counts := make(map[string]int, len(peers))
+	for dec.More() {
+		name, err := decodeKey(dec)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := expectDelim(dec, '{'); err != nil {
+			return nil, nil, err
+		}
+		var upstreamPeers []P
+		fields := make(map[string]json.RawMessage)
+		for dec.More() {
+			key, err := decodeKey(dec)
+			if err != nil {
+				return nil, nil, err
+			}
+			if key != "peers" {
+				var value json.RawMessage
+				if err := dec.Decode(&value); err != nil {
+					return nil, nil, err
+				}
+				fields[key] = value
+				continue
+			}
+			if err := expectDelim(dec, '['); err != nil {
+				return nil, nil, err
+			}
+			upstreamPeers = make([]P, 0, peers[name])
+			for dec.More() {
+				var peer P
+				upstreamPeers = append(upstreamPeers, peer)
+				if err := dec.Decode(&upstreamPeers[len(upstreamPeers)-1]); err != nil {
+					return nil, nil, err
+				}
+			}
+			if err := expectDelim(dec, ']'); err != nil {
+				return nil, nil, err
+			}
+		}
+		if err := expectDelim(dec, '}'); err != nil {
+			return nil, nil, err
+		}
+
+		var upstream U
+		data, err := json.Marshal(fields)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := json.Unmarshal(data, &upstream); err != nil {
+			return nil, nil, err
+		}
+		setPeers(&upstream, upstreamPeers)
+		upstreams[name] = upstream
+		counts[name] = len(upstreamPeers)
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return nil, nil, err
+	}
+	return upstreams, counts, nil
+}
+
+func decodeKey(dec *json.Decoder) (string, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected an object key, got %v", token)
+	}
+	return key, nil
+}
+
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	token, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, got %v", delim, token)
+	}
+	return nil
+}
+
+func (client *NginxClient) get(ctx context.Context, path string, data interface{}) error {
+	return client.decode(ctx, path, func(dec *json.Decoder) error {
+		return dec.Decode(data)
+	})
+}
+
+// decode requests the path and calls decode with a decoder of the body, so the body is decoded while it is read instead
+// of being buffered.
+func (client *NginxClient) decode(ctx context.Context, path string, decode func(dec *json.Decoder) error) error {
+	url := fmt.Sprintf("%v/%v/%v", client.apiEndpoint, client.apiVersion, path)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get %v: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	if err := decode(json.NewDecoder(resp.Body)); err != nil {
+		return fmt.Errorf("failed to parse response body: %w", &nginxclient.ParseError{Err: err})
+	}
+	return nil
+}
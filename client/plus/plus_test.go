@@ -0,0 +1,174 @@
+package plus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+func TestGetSSL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		body             string
+		noCommonProtocol *uint64
+		verifyFailures   *SSLVerifyFailures
+	}{
+		{
+			name:             "With failure reasons",
+			body:             `{"handshakes":79572,"handshakes_failed":21025,"session_reuses":15762,"no_common_protocol":4,"no_common_cipher":2,"handshake_timeout":0,"peer_rejected_cert":0,"verify_failures":{"no_cert":0,"expired_cert":2,"revoked_cert":1,"hostname_mismatch":2,"other":1}}`,
+			noCommonProtocol: uint64Ptr(4),
+			verifyFailures:   &SSLVerifyFailures{ExpiredCert: 2, RevokedCert: 1, HostnameMismatch: 2, Other: 1},
+		},
+		{
+			name: "Without failure reasons",
+			body: `{"handshakes":79572,"handshakes_failed":21025,"session_reuses":15762}`,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != "/api/9/ssl" {
+					http.NotFound(w, r)
+					return
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			ssl, err := NewNginxClient(server.Client(), server.URL+"/api/", 9).GetSSL(context.Background())
+			if err != nil {
+				t.Fatalf("GetSSL() error = %v", err)
+			}
+			if (ssl.NoCommonProtocol == nil) != (tt.noCommonProtocol == nil) ||
+				(ssl.NoCommonProtocol != nil && *ssl.NoCommonProtocol != *tt.noCommonProtocol) {
+				t.Errorf("GetSSL() no_common_protocol = %v, want %v", ssl.NoCommonProtocol, tt.noCommonProtocol)
+			}
+			if (ssl.VerifyFailures == nil) != (tt.verifyFailures == nil) ||
+				(ssl.VerifyFailures != nil && *ssl.VerifyFailures != *tt.verifyFailures) {
+				t.Errorf("GetSSL() verify_failures = %+v, want %+v", ssl.VerifyFailures, tt.verifyFailures)
+			}
+		})
+	}
+}
+
+func TestGetLicense(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/9/license":
+			_, _ = w.Write([]byte(`{"active_till":1735689600,"eval":false,"reporting":{"healthy":true,"fails":2,"grace":15552000}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	license, err := NewNginxClient(server.Client(), server.URL+"/api", 9).GetLicense(context.Background())
+	if err != nil {
+		t.Fatalf("GetLicense() error = %v", err)
+	}
+	if license.ActiveTill != 1735689600 || !license.Reporting.Healthy || license.Reporting.Fails != 2 || license.Reporting.Grace != 15552000 {
+		t.Errorf("GetLicense() = %+v", license)
+	}
+
+	license, err = NewNginxClient(server.Client(), server.URL+"/api", 8).GetLicense(context.Background())
+	if err != nil || license != nil {
+		t.Errorf("GetLicense() = %+v, %v, want nil license for a missing endpoint", license, err)
+	}
+}
+
+func TestGetUpstreams(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"backend":{"peers":[{"id":0,"server":"10.0.0.1:80","name":"10.0.0.1:80","backup":false,"weight":1,"state":"up","active":2,"requests":100,"responses":{"1xx":0,"2xx":90,"3xx":0,"4xx":8,"5xx":2,"codes":{"200":90,"404":8,"502":2},"total":100},"sent":1000,"received":20000,"fails":1,"unavail":0,"health_checks":{"checks":10,"fails":1,"unhealthy":0,"last_passed":true},"downtime":0,"selected":"2024-01-01T00:00:00Z","header_time":5,"response_time":7},{"id":1,"server":"10.0.0.2:80","name":"10.0.0.2:80","state":"down","requests":5}],"keepalive":3,"zombies":1,"zone":"backend","queue":{"size":2,"max_size":10,"overflows":4}},"empty":{"peers":[],"zombies":0,"zone":"empty"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/9/http/upstreams" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	var want plusclient.Upstreams
+	if err := json.Unmarshal([]byte(body), &want); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	client := NewNginxClient(server.Client(), server.URL+"/api", 9)
+	for scrape := 0; scrape < 2; scrape++ {
+		upstreams, err := client.GetUpstreams(context.Background())
+		if err != nil {
+			t.Fatalf("GetUpstreams() error = %v", err)
+		}
+		if !reflect.DeepEqual(*upstreams, want) {
+			t.Errorf("GetUpstreams() = %+v, want %+v", *upstreams, want)
+		}
+		if scrape == 1 && cap((*upstreams)["backend"].Peers) != 2 {
+			t.Errorf("GetUpstreams() peers capacity = %v, want the 2 peers of the previous response", cap((*upstreams)["backend"].Peers))
+		}
+	}
+}
+
+func TestGetStreamUpstreams(t *testing.T) {
+	t.Parallel()
+
+	const body = `{"tcp":{"peers":[{"id":0,"server":"10.0.0.1:5432","name":"10.0.0.1:5432","state":"up","active":1,"connections":10,"connect_time":2,"first_byte_time":3,"response_time":4,"sent":100,"received":200,"fails":0,"unavail":0,"health_checks":{"checks":1,"fails":0,"unhealthy":0}}],"zombies":0,"zone":"tcp"}}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/9/stream/upstreams" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	var want plusclient.StreamUpstreams
+	if err := json.Unmarshal([]byte(body), &want); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	upstreams, err := NewNginxClient(server.Client(), server.URL+"/api", 9).GetStreamUpstreams(context.Background())
+	if err != nil {
+		t.Fatalf("GetStreamUpstreams() error = %v", err)
+	}
+	if !reflect.DeepEqual(*upstreams, want) {
+		t.Errorf("GetStreamUpstreams() = %+v, want %+v", *upstreams, want)
+	}
+
+	upstreams, err = NewNginxClient(server.Client(), server.URL+"/api", 8).GetStreamUpstreams(context.Background())
+	if err != nil || len(*upstreams) != 0 {
+		t.Errorf("GetStreamUpstreams() = %+v, %v, want no upstreams without the stream block", *upstreams, err)
+	}
+}
+
+func TestGetUpstreamsInvalidBody(t *testing.T) {
+	t.Parallel()
+
+	for _, body := range []string{`[]`, `{"backend":{"peers":{}}}`, `{"backend":{"peers":[{"id":"a"}]}}`, `{"backend":`} {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body))
+		}))
+		_, err := NewNginxClient(server.Client(), server.URL+"/api", 9).GetUpstreams(context.Background())
+		var parseErr *nginxclient.ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("GetUpstreams() of %v error = %v, want a parse error", body, err)
+		}
+		server.Close()
+	}
+}
+
+func uint64Ptr(v uint64) *uint64 {
+	return &v
+}
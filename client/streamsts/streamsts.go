@@ -0,0 +1,92 @@
+// Package streamsts fetches the status of the nginx-module-stream-sts, the stream server traffic status module.
+package streamsts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// NginxClient allows you to fetch the traffic of the TCP/UDP server and upstream zones from the
+// stream_server_traffic_status_display page.
+type NginxClient struct {
+	apiEndpoint string
+	httpClient  *http.Client
+}
+
+// Status represents the traffic of the TCP/UDP server and upstream zones.
+type Status struct {
+	StreamServerZones   map[string]Zone             `json:"streamServerZones"`
+	StreamUpstreamZones map[string][]UpstreamServer `json:"streamUpstreamZones"`
+}
+
+// Zone represents the traffic of a stream server zone. The "*" zone sums up all the server zones.
+type Zone struct {
+	Port           int       `json:"port"`
+	Protocol       string    `json:"protocol"`
+	ConnectCounter uint64    `json:"connectCounter"`
+	InBytes        uint64    `json:"inBytes"`
+	OutBytes       uint64    `json:"outBytes"`
+	Responses      Responses `json:"responses"`
+}
+
+// UpstreamServer represents the traffic of a server of a stream upstream group.
+type UpstreamServer struct {
+	Server         string    `json:"server"`
+	ConnectCounter uint64    `json:"connectCounter"`
+	InBytes        uint64    `json:"inBytes"`
+	OutBytes       uint64    `json:"outBytes"`
+	Responses      Responses `json:"responses"`
+	Backup         bool      `json:"backup"`
+	Down           bool      `json:"down"`
+}
+
+// Responses represents the sessions by the class of the session status code.
+type Responses struct {
+	Responses1xx uint64 `json:"1xx"`
+	Responses2xx uint64 `json:"2xx"`
+	Responses3xx uint64 `json:"3xx"`
+	Responses4xx uint64 `json:"4xx"`
+	Responses5xx uint64 `json:"5xx"`
+}
+
+// NewNginxClient creates an NginxClient. The display page must return the json format,
+// e.g. http://127.0.0.1:8080/stream-status/format/json.
+func NewNginxClient(httpClient *http.Client, apiEndpoint string) *NginxClient {
+	return &NginxClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+}
+
+// GetStatus fetches the traffic status. The request is canceled when ctx is done.
+func (client *NginxClient) GetStatus(ctx context.Context) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.apiEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", client.apiEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal(body, status); err != nil {
+		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), &nginxclient.ParseError{Err: err})
+	}
+	return status, nil
+}
@@ -0,0 +1,89 @@
+package streamsts
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+const validStatus = `{
+	"hostName": "nginx", "nginxVersion": "1.25.3", "loadMsec": 1700000000000, "nowMsec": 1700000060000,
+	"streamServerZones": {
+		"dns": {"port": 53, "protocol": "UDP", "connectCounter": 10, "inBytes": 400, "outBytes": 800,
+			"responses": {"1xx": 0, "2xx": 9, "3xx": 0, "4xx": 0, "5xx": 1}, "sessionMsecCounter": 20, "sessionMsec": 2}
+	},
+	"streamUpstreamZones": {
+		"dns_backends": [
+			{"server": "10.0.0.1:53", "connectCounter": 9, "inBytes": 360, "outBytes": 720,
+				"responses": {"1xx": 0, "2xx": 9, "3xx": 0, "4xx": 0, "5xx": 0}, "weight": 1, "backup": false, "down": false}
+		]
+	}
+}`
+
+func TestGetStatus(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(validStatus))
+	}))
+	defer server.Close()
+
+	status, err := NewNginxClient(server.Client(), server.URL).GetStatus(context.Background())
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+
+	expected := &Status{
+		StreamServerZones: map[string]Zone{
+			"dns": {
+				Port: 53, Protocol: "UDP", ConnectCounter: 10, InBytes: 400, OutBytes: 800,
+				Responses: Responses{Responses2xx: 9, Responses5xx: 1},
+			},
+		},
+		StreamUpstreamZones: map[string][]UpstreamServer{
+			"dns_backends": {
+				{Server: "10.0.0.1:53", ConnectCounter: 9, InBytes: 360, OutBytes: 720, Responses: Responses{Responses2xx: 9}},
+			},
+		},
+	}
+	if !reflect.DeepEqual(status, expected) {
+		t.Errorf("GetStatus() = %+v, want %+v", status, expected)
+	}
+}
+
+func TestGetStatusErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+	}{
+		{name: "not found", statusCode: http.StatusNotFound},
+		{name: "html format", statusCode: http.StatusOK, body: "<html>stream status</html>"},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(test.statusCode)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			_, err := NewNginxClient(server.Client(), server.URL).GetStatus(context.Background())
+			var statusErr *nginxclient.StatusError
+			var parseErr *nginxclient.ParseError
+			if !errors.As(err, &statusErr) && !errors.As(err, &parseErr) {
+				t.Errorf("GetStatus() error = %v, want a status or a parse error", err)
+			}
+		})
+	}
+}
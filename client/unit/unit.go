@@ -3,15 +3,28 @@ package unit
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
 )
 
+// isNotFound reports whether the requested object does not exist, e.g. the routes of a configuration without routes.
+func isNotFound(err error) bool {
+	var statusErr *nginxclient.StatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusNotFound
+}
+
 // NginxClient allows you to fetch NGINX metrics from the status page.
 type NginxClient struct {
-	apiEndpoint string
-	httpClient  *http.Client
+	apiEndpoint     string
+	controlEndpoint string
+	httpClient      *http.Client
 }
 
 // Status represents NGINX metrics.
@@ -33,15 +46,246 @@ type Status struct {
 		} `json:"processes"`
 		Requests struct {
 			Active int `json:"active"`
+			// Total is nil for the Unit versions that do not report it.
+			Total *int64 `json:"total"`
 		} `json:"requests"`
 	} `json:"applications"`
+	// Modules are the loaded language modules by language, available since Unit 1.32.
+	Modules map[string]Modules `json:"modules"`
+	// Version is the Unit version reported in the Server header of the response.
+	Version string `json:"-"`
+}
+
+// Modules are the loaded modules of a language, one for every language version.
+type Modules []Module
+
+// UnmarshalJSON decodes both a single module object and an array of module objects of the same language.
+func (m *Modules) UnmarshalJSON(data []byte) error {
+	var module Module
+	if err := json.Unmarshal(data, &module); err == nil {
+		*m = Modules{module}
+		return nil
+	}
+
+	var modules []Module
+	if err := json.Unmarshal(data, &modules); err != nil {
+		return err
+	}
+	*m = modules
+	return nil
+}
+
+// Module represents a loaded language module.
+type Module struct {
+	Version string `json:"version"`
+	Lib     string `json:"lib"`
+}
+
+// Listeners is a map of listener configurations by listener address.
+type Listeners map[string]Listener
+
+// Listener represents the configuration of a single listener.
+type Listener struct {
+	Pass string `json:"pass"`
+	// Application is the pre-1.11 way of binding a listener to an application.
+	Application string `json:"application"`
+}
+
+// Target returns the type (applications, routes or upstreams) and the name of the object the listener passes requests to.
+func (l Listener) Target() (string, string) {
+	if l.Pass == "" && l.Application != "" {
+		return "applications", l.Application
+	}
+	return parsePass(l.Pass)
+}
+
+// Routes is a map of route steps by route name. A configuration with a single unnamed route
+// is stored by the empty name, which is also the target name of a "routes" pass.
+type Routes map[string][]RouteStep
+
+// UnmarshalJSON decodes both the named routes object and the single unnamed route array.
+func (r *Routes) UnmarshalJSON(data []byte) error {
+	var steps []RouteStep
+	if err := json.Unmarshal(data, &steps); err == nil {
+		*r = Routes{"": steps}
+		return nil
+	}
+
+	routes := map[string][]RouteStep{}
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return err
+	}
+	*r = routes
+	return nil
+}
+
+// RouteStep represents a single step of a route.
+type RouteStep struct {
+	Action struct {
+		Pass string `json:"pass"`
+	} `json:"action"`
+}
+
+// Target returns the type (applications, routes or upstreams) and the name of the object the step passes requests to.
+// Both are empty for the steps that serve static files, proxy or return responses.
+func (s RouteStep) Target() (string, string) {
+	if s.Action.Pass == "" {
+		return "", ""
+	}
+	return parsePass(s.Action.Pass)
+}
+
+func parsePass(pass string) (string, string) {
+	parts := strings.SplitN(pass, "/", 3)
+	if len(parts) < 2 {
+		return pass, ""
+	}
+	return parts[0], parts[1]
 }
 
-// NewNginxClient creates an NginxClient.
+// Upstreams is a map of upstream configurations by upstream name.
+type Upstreams map[string]Upstream
+
+// Upstream represents the configuration of a single upstream.
+type Upstream struct {
+	Servers map[string]UpstreamServer `json:"servers"`
+}
+
+// UpstreamServer represents the configuration of a single server of an upstream.
+type UpstreamServer struct {
+	Weight *float64 `json:"weight"`
+}
+
+// EffectiveWeight returns the weight of the server, 1 when it is not configured.
+func (s UpstreamServer) EffectiveWeight() float64 {
+	if s.Weight == nil {
+		return 1
+	}
+	return *s.Weight
+}
+
+// Certificates is a map of certificate bundles by bundle name.
+type Certificates map[string]CertificateBundle
+
+// CertificateBundle represents the metadata of an uploaded certificate bundle.
+type CertificateBundle struct {
+	Key   string        `json:"key"`
+	Chain []Certificate `json:"chain"`
+}
+
+// Certificate represents the metadata of a single certificate of a bundle chain.
+type Certificate struct {
+	Validity struct {
+		Since string `json:"since"`
+		Until string `json:"until"`
+	} `json:"validity"`
+}
+
+// certificateTimeLayout is the format of the certificate validity times, e.g. "Sep 18 19:46:19 2022 GMT".
+const certificateTimeLayout = "Jan _2 15:04:05 2006 MST"
+
+// Expiry returns the time the certificate expires.
+func (c Certificate) Expiry() (time.Time, error) {
+	return time.Parse(certificateTimeLayout, c.Validity.Until)
+}
+
+// Expiry returns the time the first certificate of the chain expires.
+func (b CertificateBundle) Expiry() (time.Time, error) {
+	var expiry time.Time
+	for _, c := range b.Chain {
+		until, err := c.Expiry()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse certificate expiry %q: %w", c.Validity.Until, err)
+		}
+		if expiry.IsZero() || until.Before(expiry) {
+			expiry = until
+		}
+	}
+	if expiry.IsZero() {
+		return time.Time{}, errors.New("certificate chain is empty")
+	}
+	return expiry, nil
+}
+
+// Applications is a map of application configurations by application name.
+type Applications map[string]Application
+
+// Application represents the configuration of a single application.
+type Application struct {
+	Type      string                `json:"type"`
+	User      string                `json:"user"`
+	Group     string                `json:"group"`
+	Processes *ApplicationProcesses `json:"processes"`
+	Isolation ApplicationIsolation  `json:"isolation"`
+}
+
+// ApplicationIsolation represents the isolation settings of an application.
+type ApplicationIsolation struct {
+	Namespaces map[string]bool `json:"namespaces"`
+	Rootfs     string          `json:"rootfs"`
+	Cgroup     struct {
+		Path string `json:"path"`
+	} `json:"cgroup"`
+}
+
+// EnabledNamespaces returns the sorted names of the namespaces the application runs in.
+func (i ApplicationIsolation) EnabledNamespaces() []string {
+	var namespaces []string
+	for name, enabled := range i.Namespaces {
+		if enabled {
+			namespaces = append(namespaces, name)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// ApplicationProcesses represents the process limits of an application.
+type ApplicationProcesses struct {
+	Max   int `json:"max"`
+	Spare int `json:"spare"`
+}
+
+// UnmarshalJSON decodes both the dynamic process limits object and the static number of processes.
+func (p *ApplicationProcesses) UnmarshalJSON(data []byte) error {
+	var count int
+	if err := json.Unmarshal(data, &count); err == nil {
+		*p = ApplicationProcesses{Max: count, Spare: count}
+		return nil
+	}
+
+	// max defaults to 1 and spare to 0 in NGINX Unit
+	type limits ApplicationProcesses
+	l := limits{Max: 1}
+	if err := json.Unmarshal(data, &l); err != nil {
+		return err
+	}
+	*p = ApplicationProcesses(l)
+	return nil
+}
+
+// ProcessLimits returns the maximum and the spare number of application processes,
+// one process when the limits are not configured.
+func (a Application) ProcessLimits() (int, int) {
+	if a.Processes == nil {
+		return 1, 1
+	}
+	return a.Processes.Max, a.Processes.Spare
+}
+
+// Language returns the application type without the optional language version, e.g. "php" for "php 8.2".
+func (a Application) Language() string {
+	language, _, _ := strings.Cut(a.Type, " ")
+	return language
+}
+
+// NewNginxClient creates an NginxClient for the URI of the status endpoint. For the control API over a unix domain
+// socket, the httpClient must dial the socket.
 func NewNginxClient(httpClient *http.Client, apiEndpoint string) (*NginxClient, error) {
 	client := &NginxClient{
-		apiEndpoint: apiEndpoint,
-		httpClient:  httpClient,
+		apiEndpoint:     apiEndpoint,
+		controlEndpoint: strings.TrimSuffix(strings.TrimSuffix(apiEndpoint, "/"), "/status"),
+		httpClient:      httpClient,
 	}
 
 	_, err := client.GetStatus()
@@ -50,33 +294,154 @@ func NewNginxClient(httpClient *http.Client, apiEndpoint string) (*NginxClient,
 
 // GetStatus fetches the metrics.
 func (client *NginxClient) GetStatus() (*Status, error) {
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
+	return client.GetStatusWithContext(context.Background())
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.apiEndpoint, nil)
+// GetStatusWithContext fetches the metrics. The request is canceled when ctx is done.
+func (client *NginxClient) GetStatusWithContext(ctx context.Context) (*Status, error) {
+	status := &Status{}
+	header, err := client.get(ctx, client.apiEndpoint, status)
+	if isNotFound(err) {
+		return nil, fmt.Errorf("the status endpoint is available since NGINX Unit 1.24: %w", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	status.Version = parseVersion(header)
+	return status, nil
+}
+
+// GetListeners fetches the listeners configuration.
+func (client *NginxClient) GetListeners() (Listeners, error) {
+	return client.GetListenersWithContext(context.Background())
+}
+
+// GetListenersWithContext fetches the listeners configuration. The request is canceled when ctx is done.
+func (client *NginxClient) GetListenersWithContext(ctx context.Context) (Listeners, error) {
+	listeners := Listeners{}
+	_, err := client.get(ctx, client.controlEndpoint+"/config/listeners", &listeners)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get listeners: %w", err)
+	}
+	return listeners, nil
+}
+
+// GetConfig fetches the whole configuration.
+func (client *NginxClient) GetConfig() (map[string]interface{}, error) {
+	return client.GetConfigWithContext(context.Background())
+}
+
+// GetConfigWithContext fetches the whole configuration. The request is canceled when ctx is done.
+func (client *NginxClient) GetConfigWithContext(ctx context.Context) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	_, err := client.get(ctx, client.controlEndpoint+"/config", &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get config: %w", err)
+	}
+	return config, nil
+}
+
+// GetRoutes fetches the routes configuration.
+func (client *NginxClient) GetRoutes() (Routes, error) {
+	return client.GetRoutesWithContext(context.Background())
+}
+
+// GetRoutesWithContext fetches the routes configuration. The request is canceled when ctx is done.
+func (client *NginxClient) GetRoutesWithContext(ctx context.Context) (Routes, error) {
+	routes := Routes{}
+	_, err := client.get(ctx, client.controlEndpoint+"/config/routes", &routes)
+	if isNotFound(err) {
+		return Routes{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes: %w", err)
+	}
+	return routes, nil
+}
+
+// GetUpstreams fetches the upstreams configuration.
+func (client *NginxClient) GetUpstreams() (Upstreams, error) {
+	return client.GetUpstreamsWithContext(context.Background())
+}
+
+// GetUpstreamsWithContext fetches the upstreams configuration. The request is canceled when ctx is done.
+func (client *NginxClient) GetUpstreamsWithContext(ctx context.Context) (Upstreams, error) {
+	upstreams := Upstreams{}
+	_, err := client.get(ctx, client.controlEndpoint+"/config/upstreams", &upstreams)
+	if isNotFound(err) {
+		return Upstreams{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get upstreams: %w", err)
+	}
+	return upstreams, nil
+}
+
+// GetCertificates fetches the metadata of the certificate bundles.
+func (client *NginxClient) GetCertificates() (Certificates, error) {
+	return client.GetCertificatesWithContext(context.Background())
+}
+
+// GetCertificatesWithContext fetches the metadata of the certificate bundles. The request is canceled when ctx is done.
+func (client *NginxClient) GetCertificatesWithContext(ctx context.Context) (Certificates, error) {
+	certificates := Certificates{}
+	_, err := client.get(ctx, client.controlEndpoint+"/certificates", &certificates)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get certificates: %w", err)
+	}
+	return certificates, nil
+}
+
+// GetApplications fetches the applications configuration.
+func (client *NginxClient) GetApplications() (Applications, error) {
+	return client.GetApplicationsWithContext(context.Background())
+}
+
+// GetApplicationsWithContext fetches the applications configuration. The request is canceled when ctx is done.
+func (client *NginxClient) GetApplicationsWithContext(ctx context.Context) (Applications, error) {
+	applications := Applications{}
+	_, err := client.get(ctx, client.controlEndpoint+"/config/applications", &applications)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get applications: %w", err)
+	}
+	return applications, nil
+}
+
+// parseVersion extracts the Unit version from the Server (e.g. "Unit/1.31.0") or X-Unit-Version header.
+func parseVersion(header http.Header) string {
+	if v := header.Get("X-Unit-Version"); v != "" {
+		return v
+	}
+	if name, v, found := strings.Cut(header.Get("Server"), "/"); found && name == "Unit" {
+		return v
+	}
+	return ""
+}
+
+func (client *NginxClient) get(ctx context.Context, url string, data interface{}) (http.Header, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create a get request: %w", err)
 	}
 	resp, err := client.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get %v: %w", client.apiEndpoint, err)
+		return nil, fmt.Errorf("failed to get %v: %w", url, err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("expected %v response, got %v", http.StatusOK, resp.StatusCode)
+		return nil, &nginxclient.StatusError{StatusCode: resp.StatusCode}
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read the response body: %w", err)
 	}
-	status := &Status{}
 
-	err = json.Unmarshal(body, status)
+	err = json.Unmarshal(body, data)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), err)
+		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), &nginxclient.ParseError{Err: err})
 	}
 
-	return status, nil
+	return resp.Header, nil
 }
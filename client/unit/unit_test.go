@@ -0,0 +1,310 @@
+package unit
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestListenerTarget(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name           string
+		listener       Listener
+		wantTargetType string
+		wantTarget     string
+	}{
+		{
+			name:           "pass to application",
+			listener:       Listener{Pass: "applications/blogs"},
+			wantTargetType: "applications",
+			wantTarget:     "blogs",
+		},
+		{
+			name:           "pass to application target",
+			listener:       Listener{Pass: "applications/blogs/admin"},
+			wantTargetType: "applications",
+			wantTarget:     "blogs",
+		},
+		{
+			name:           "pass to route",
+			listener:       Listener{Pass: "routes/main"},
+			wantTargetType: "routes",
+			wantTarget:     "main",
+		},
+		{
+			name:           "legacy application option",
+			listener:       Listener{Application: "blogs"},
+			wantTargetType: "applications",
+			wantTarget:     "blogs",
+		},
+		{
+			name:           "malformed pass",
+			listener:       Listener{Pass: "routes"},
+			wantTargetType: "routes",
+			wantTarget:     "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targetType, target := tt.listener.Target()
+			if targetType != tt.wantTargetType {
+				t.Errorf("Target() type = %v, want %v", targetType, tt.wantTargetType)
+			}
+			if target != tt.wantTarget {
+				t.Errorf("Target() name = %v, want %v", target, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestParseVersion(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{
+			name:   "server header",
+			header: http.Header{"Server": []string{"Unit/1.31.0"}},
+			want:   "1.31.0",
+		},
+		{
+			name:   "version header takes precedence",
+			header: http.Header{"Server": []string{"Unit/1.31.0"}, "X-Unit-Version": []string{"1.31.1"}},
+			want:   "1.31.1",
+		},
+		{
+			name:   "foreign server header",
+			header: http.Header{"Server": []string{"nginx/1.25.2"}},
+			want:   "",
+		},
+		{
+			name:   "no headers",
+			header: http.Header{},
+			want:   "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseVersion(tt.header); got != tt.want {
+				t.Errorf("parseVersion() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoutesUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	pass := func(pass string) RouteStep {
+		var step RouteStep
+		step.Action.Pass = pass
+		return step
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Routes
+		wantErr bool
+	}{
+		{
+			name: "named routes",
+			data: `{"main":[{"match":{"uri":"/api/*"},"action":{"pass":"applications/blogs"}},{"action":{"share":"/www$uri"}}]}`,
+			want: Routes{"main": {pass("applications/blogs"), pass("")}},
+		},
+		{
+			name: "unnamed route",
+			data: `[{"action":{"pass":"applications/blogs"}}]`,
+			want: Routes{"": {pass("applications/blogs")}},
+		},
+		{
+			name:    "malformed routes",
+			data:    `"routes"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Routes
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModulesUnmarshalJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    Modules
+		wantErr bool
+	}{
+		{
+			name: "single module",
+			data: `{"version":"8.2.10","lib":"/usr/lib/unit/modules/php.unit.so"}`,
+			want: Modules{{Version: "8.2.10", Lib: "/usr/lib/unit/modules/php.unit.so"}},
+		},
+		{
+			name: "several versions",
+			data: `[{"version":"3.11.4","lib":"/usr/lib/unit/modules/python3.11.unit.so"},{"version":"3.12.1","lib":"/usr/lib/unit/modules/python3.12.unit.so"}]`,
+			want: Modules{
+				{Version: "3.11.4", Lib: "/usr/lib/unit/modules/python3.11.unit.so"},
+				{Version: "3.12.1", Lib: "/usr/lib/unit/modules/python3.12.unit.so"},
+			},
+		},
+		{
+			name:    "malformed modules",
+			data:    `"php"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Modules
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationProcessLimits(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		data      string
+		wantMax   int
+		wantSpare int
+	}{
+		{
+			name:      "dynamic processes",
+			data:      `{"type":"php","processes":{"max":10,"spare":2,"idle_timeout":20}}`,
+			wantMax:   10,
+			wantSpare: 2,
+		},
+		{
+			name:      "dynamic processes with defaults",
+			data:      `{"type":"php","processes":{}}`,
+			wantMax:   1,
+			wantSpare: 0,
+		},
+		{
+			name:      "static processes",
+			data:      `{"type":"php","processes":4}`,
+			wantMax:   4,
+			wantSpare: 4,
+		},
+		{
+			name:      "no processes",
+			data:      `{"type":"php"}`,
+			wantMax:   1,
+			wantSpare: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var application Application
+			if err := json.Unmarshal([]byte(tt.data), &application); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			gotMax, gotSpare := application.ProcessLimits()
+			if gotMax != tt.wantMax {
+				t.Errorf("ProcessLimits() max = %v, want %v", gotMax, tt.wantMax)
+			}
+			if gotSpare != tt.wantSpare {
+				t.Errorf("ProcessLimits() spare = %v, want %v", gotSpare, tt.wantSpare)
+			}
+		})
+	}
+}
+
+func TestCertificateBundleExpiry(t *testing.T) {
+	t.Parallel()
+
+	certificate := func(until string) Certificate {
+		var c Certificate
+		c.Validity.Until = until
+		return c
+	}
+
+	tests := []struct {
+		name    string
+		bundle  CertificateBundle
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "earliest expiry of the chain",
+			bundle: CertificateBundle{Chain: []Certificate{certificate("Jun 15 19:46:19 2025 GMT"), certificate("Sep  8 01:02:03 2024 GMT")}},
+			want:   time.Date(2024, time.September, 8, 1, 2, 3, 0, time.UTC),
+		},
+		{
+			name:    "empty chain",
+			bundle:  CertificateBundle{},
+			wantErr: true,
+		},
+		{
+			name:    "malformed expiry",
+			bundle:  CertificateBundle{Chain: []Certificate{certificate("2025-06-15")}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.bundle.Expiry()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expiry() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Expiry() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplicationIsolationEnabledNamespaces(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		isolation ApplicationIsolation
+		want      []string
+	}{
+		{
+			name:      "enabled namespaces",
+			isolation: ApplicationIsolation{Namespaces: map[string]bool{"pid": true, "credential": true, "network": false}},
+			want:      []string{"credential", "pid"},
+		},
+		{
+			name:      "no namespaces",
+			isolation: ApplicationIsolation{},
+			want:      nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.isolation.EnabledNamespaces(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EnabledNamespaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
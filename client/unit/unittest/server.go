@@ -0,0 +1,197 @@
+// Package unittest provides a fake NGINX Unit control API server for tests of the code that uses the unit client
+// or the NGINX Unit collector.
+package unittest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake NGINX Unit control API. It serves the /status, /config and /certificates endpoints
+// with the configured payloads. It is safe to change the payloads while the server is running.
+type Server struct {
+	*httptest.Server
+
+	mutex        sync.Mutex
+	status       *Status
+	config       map[string]interface{}
+	certificates map[string]interface{}
+	version      string
+	latency      time.Duration
+	errors       map[string]int
+}
+
+// NewServer starts a fake NGINX Unit control API with an empty status and configuration.
+// The caller must call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		status:       NewStatus(),
+		config:       map[string]interface{}{"listeners": map[string]interface{}{}, "applications": map[string]interface{}{}},
+		certificates: map[string]interface{}{},
+		version:      "1.31.0",
+		errors:       map[string]int{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// StatusURL returns the URI of the status endpoint to pass to the unit client.
+func (s *Server) StatusURL() string {
+	return s.URL + "/status"
+}
+
+// SetStatus sets the payload of the /status endpoint.
+func (s *Server) SetStatus(status *Status) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status = status
+}
+
+// SetConfig sets the object at the path of the configuration, e.g. "applications/blogs" or "routes".
+// The value must be encodable to JSON.
+func (s *Server) SetConfig(path string, value interface{}) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	object := s.config
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := object[part].(map[string]interface{})
+		if !ok {
+			child = map[string]interface{}{}
+			object[part] = child
+		}
+		object = child
+	}
+	object[parts[len(parts)-1]] = value
+}
+
+// DeleteConfig removes the object at the path of the configuration.
+func (s *Server) DeleteConfig(path string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	object := s.config
+	for _, part := range parts[:len(parts)-1] {
+		child, ok := object[part].(map[string]interface{})
+		if !ok {
+			return
+		}
+		object = child
+	}
+	delete(object, parts[len(parts)-1])
+}
+
+// SetCertificate sets the metadata of a certificate bundle, the chain certificates expire at the given times.
+func (s *Server) SetCertificate(name string, expiry ...time.Time) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chain := make([]interface{}, 0, len(expiry))
+	for _, until := range expiry {
+		chain = append(chain, map[string]interface{}{
+			"validity": map[string]interface{}{
+				"until": until.UTC().Format("Jan _2 15:04:05 2006 GMT"),
+			},
+		})
+	}
+	s.certificates[name] = map[string]interface{}{"key": "RSA (2048 bits)", "chain": chain}
+}
+
+// SetVersion sets the version reported in the Server header. An empty version removes the header.
+func (s *Server) SetVersion(version string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.version = version
+}
+
+// SetLatency delays every response by latency.
+func (s *Server) SetLatency(latency time.Duration) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.latency = latency
+}
+
+// SetError makes the requests of the path, e.g. "/status" or "/config/routes", fail with the HTTP status code.
+// A zero code removes the error.
+func (s *Server) SetError(path string, code int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if code == 0 {
+		delete(s.errors, path)
+		return
+	}
+	s.errors[path] = code
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	latency := s.latency
+	s.mutex.Unlock()
+
+	if latency > 0 {
+		select {
+		case <-time.After(latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	if code, ok := s.errors[path]; ok {
+		http.Error(w, `{"error": "Injected error."}`, code)
+		return
+	}
+
+	var body interface{}
+	var found bool
+	switch {
+	case path == "/status":
+		body, found = s.status, true
+	case path == "/certificates":
+		body, found = s.certificates, true
+	case path == "/config" || strings.HasPrefix(path, "/config/"):
+		body, found = lookup(s.config, strings.TrimPrefix(path, "/config"))
+	}
+	if !found {
+		http.Error(w, `{"error": "Value doesn't exist."}`, http.StatusNotFound)
+		return
+	}
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if s.version != "" {
+		w.Header().Set("Server", "Unit/"+s.version)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(data)
+}
+
+func lookup(config map[string]interface{}, path string) (interface{}, bool) {
+	var value interface{} = config
+	for _, part := range strings.Split(strings.Trim(path, "/"), "/") {
+		if part == "" {
+			continue
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = object[part]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
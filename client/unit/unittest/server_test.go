@@ -0,0 +1,77 @@
+package unittest
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
+)
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	server := NewServer()
+	defer server.Close()
+
+	server.SetStatus(NewStatus().WithConnections(10, 2, 1, 7).WithRequests(42).WithApplication("blogs", 2, 0, 1, 1, 40))
+	server.SetConfig("applications/blogs", map[string]interface{}{"type": "php 8.2", "user": "www"})
+	server.SetConfig("listeners/*:8080", map[string]interface{}{"pass": "applications/blogs"})
+
+	client, err := unitclient.NewNginxClient(server.Client(), server.StatusURL())
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+
+	status, err := client.GetStatus()
+	if err != nil {
+		t.Fatalf("GetStatus() error = %v", err)
+	}
+	if status.Version != "1.31.0" {
+		t.Errorf("GetStatus() version = %v, want 1.31.0", status.Version)
+	}
+	if status.Requests.Total != 42 {
+		t.Errorf("GetStatus() requests = %v, want 42", status.Requests.Total)
+	}
+	if got := status.Applications["blogs"].Processes.Running; got != 2 {
+		t.Errorf("GetStatus() running processes = %v, want 2", got)
+	}
+
+	applications, err := client.GetApplications()
+	if err != nil {
+		t.Fatalf("GetApplications() error = %v", err)
+	}
+	if got := applications["blogs"].Language(); got != "php" {
+		t.Errorf("GetApplications() language = %v, want php", got)
+	}
+
+	listeners, err := client.GetListeners()
+	if err != nil {
+		t.Fatalf("GetListeners() error = %v", err)
+	}
+	if _, target := listeners["*:8080"].Target(); target != "blogs" {
+		t.Errorf("GetListeners() target = %v, want blogs", target)
+	}
+
+	routes, err := client.GetRoutes()
+	if err != nil {
+		t.Fatalf("GetRoutes() error = %v", err)
+	}
+	if len(routes) != 0 {
+		t.Errorf("GetRoutes() = %v, want no routes", routes)
+	}
+
+	server.SetError("/status", http.StatusServiceUnavailable)
+	if _, err := client.GetStatus(); err == nil {
+		t.Error("GetStatus() error = nil, want injected error")
+	}
+	server.SetError("/status", 0)
+
+	server.SetLatency(time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := client.GetStatusWithContext(ctx); err == nil {
+		t.Error("GetStatusWithContext() error = nil, want deadline error")
+	}
+}
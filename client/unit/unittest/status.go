@@ -0,0 +1,79 @@
+package unittest
+
+// Status is a builder of the /status payload of the fake server.
+type Status struct {
+	Connections  StatusConnections             `json:"connections"`
+	Requests     StatusRequests                `json:"requests"`
+	Applications map[string]*StatusApplication `json:"applications"`
+	Modules      map[string][]StatusModule     `json:"modules,omitempty"`
+}
+
+// StatusConnections are the connection counters of the status.
+type StatusConnections struct {
+	Accepted int64 `json:"accepted"`
+	Active   int64 `json:"active"`
+	Idle     int64 `json:"idle"`
+	Closed   int64 `json:"closed"`
+}
+
+// StatusRequests are the request counters of the status.
+type StatusRequests struct {
+	Total int64 `json:"total"`
+}
+
+// StatusApplication is the status of a single application.
+type StatusApplication struct {
+	Processes struct {
+		Running  int `json:"running"`
+		Starting int `json:"starting"`
+		Idle     int `json:"idle"`
+	} `json:"processes"`
+	Requests struct {
+		Active int    `json:"active"`
+		Total  *int64 `json:"total,omitempty"`
+	} `json:"requests"`
+}
+
+// StatusModule is a loaded language module.
+type StatusModule struct {
+	Version string `json:"version"`
+	Lib     string `json:"lib"`
+}
+
+// NewStatus creates an empty status.
+func NewStatus() *Status {
+	return &Status{Applications: map[string]*StatusApplication{}}
+}
+
+// WithConnections sets the connection counters.
+func (s *Status) WithConnections(accepted, active, idle, closed int64) *Status {
+	s.Connections = StatusConnections{Accepted: accepted, Active: active, Idle: idle, Closed: closed}
+	return s
+}
+
+// WithRequests sets the total requests.
+func (s *Status) WithRequests(total int64) *Status {
+	s.Requests.Total = total
+	return s
+}
+
+// WithApplication adds the status of an application with the process counts and the active and total requests.
+func (s *Status) WithApplication(name string, running, starting, idle, activeRequests int, totalRequests int64) *Status {
+	application := &StatusApplication{}
+	application.Processes.Running = running
+	application.Processes.Starting = starting
+	application.Processes.Idle = idle
+	application.Requests.Active = activeRequests
+	application.Requests.Total = &totalRequests
+	s.Applications[name] = application
+	return s
+}
+
+// WithModule adds a loaded language module.
+func (s *Status) WithModule(language, version string) *Status {
+	if s.Modules == nil {
+		s.Modules = map[string][]StatusModule{}
+	}
+	s.Modules[language] = append(s.Modules[language], StatusModule{Version: version, Lib: "/usr/lib/unit/modules/" + language + ".unit.so"})
+	return s
+}
@@ -0,0 +1,132 @@
+// Package upstreamcheck fetches the status page of the nginx_upstream_check_module used by Tengine and OpenResty.
+package upstreamcheck
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// NginxClient allows you to fetch the health checks of the upstream servers from the check_status page.
+type NginxClient struct {
+	apiEndpoint string
+	httpClient  *http.Client
+}
+
+// Status represents the health checks of the upstream servers.
+type Status struct {
+	Servers struct {
+		Total      int      `json:"total"`
+		Generation int      `json:"generation"`
+		Server     []Server `json:"server"`
+	} `json:"servers"`
+}
+
+// Server represents the health check of an upstream server.
+type Server struct {
+	Index    int    `json:"index"`
+	Upstream string `json:"upstream"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Rise     int    `json:"rise"`
+	Fall     int    `json:"fall"`
+	Type     string `json:"type"`
+	Port     int    `json:"port"`
+}
+
+// NewNginxClient creates an NginxClient. The check_status page must return the json or the csv format,
+// e.g. http://127.0.0.1:8080/status?format=json.
+func NewNginxClient(httpClient *http.Client, apiEndpoint string) *NginxClient {
+	return &NginxClient{
+		apiEndpoint: apiEndpoint,
+		httpClient:  httpClient,
+	}
+}
+
+// GetStatus fetches the health checks. The request is canceled when ctx is done.
+func (client *NginxClient) GetStatus(ctx context.Context) (*Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, client.apiEndpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := client.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %v: %w", client.apiEndpoint, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	status, err := parseStatus(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse response body %q: %w", string(body), &nginxclient.ParseError{Err: err})
+	}
+	return status, nil
+}
+
+// parseStatus parses the json or the csv format of the check_status page.
+func parseStatus(body []byte) (*Status, error) {
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '{' {
+		status := &Status{}
+		if err := json.Unmarshal(trimmed, status); err != nil {
+			return nil, err
+		}
+		return status, nil
+	}
+	return parseCSVStatus(body)
+}
+
+// parseCSVStatus parses the csv format, a line per server: index,upstream,name,status,rise,fall,type,port.
+// The csv format does not report the generation.
+func parseCSVStatus(body []byte) (*Status, error) {
+	status := &Status{}
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		if len(fields) != 8 {
+			return nil, fmt.Errorf("expected 8 fields, got %v in %q", len(fields), line)
+		}
+		numbers := make([]int, 0, 4)
+		for _, field := range []string{fields[0], fields[4], fields[5], fields[7]} {
+			n, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse %q: %w", line, err)
+			}
+			numbers = append(numbers, n)
+		}
+		status.Servers.Server = append(status.Servers.Server, Server{
+			Index:    numbers[0],
+			Upstream: fields[1],
+			Name:     fields[2],
+			Status:   fields[3],
+			Rise:     numbers[1],
+			Fall:     numbers[2],
+			Type:     fields[6],
+			Port:     numbers[3],
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	status.Servers.Total = len(status.Servers.Server)
+	return status, nil
+}
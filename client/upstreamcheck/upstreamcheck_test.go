@@ -0,0 +1,71 @@
+package upstreamcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetStatus(t *testing.T) {
+	t.Parallel()
+
+	servers := []Server{
+		{Index: 0, Upstream: "backend", Name: "10.0.0.1:80", Status: "up", Rise: 58, Fall: 0, Type: "http", Port: 80},
+		{Index: 1, Upstream: "backend", Name: "10.0.0.2:80", Status: "down", Rise: 0, Fall: 3, Type: "tcp", Port: 0},
+	}
+	tests := []struct {
+		name       string
+		body       string
+		generation int
+	}{
+		{
+			name: "json",
+			body: `{"servers": {"total": 2, "generation": 3, "server": [
+				{"index": 0, "upstream": "backend", "name": "10.0.0.1:80", "status": "up", "rise": 58, "fall": 0, "type": "http", "port": 80},
+				{"index": 1, "upstream": "backend", "name": "10.0.0.2:80", "status": "down", "rise": 0, "fall": 3, "type": "tcp", "port": 0}
+			]}}`,
+			generation: 3,
+		},
+		{
+			name: "csv",
+			body: "0,backend,10.0.0.1:80,up,58,0,http,80\n1,backend,10.0.0.2:80,down,0,3,tcp,0\n",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer server.Close()
+
+			status, err := NewNginxClient(server.Client(), server.URL+"/status").GetStatus(context.Background())
+			if err != nil {
+				t.Fatalf("GetStatus() error = %v", err)
+			}
+			if !reflect.DeepEqual(status.Servers.Server, servers) {
+				t.Errorf("GetStatus() servers = %+v, want %+v", status.Servers.Server, servers)
+			}
+			if status.Servers.Total != 2 || status.Servers.Generation != test.generation {
+				t.Errorf("GetStatus() total = %v, generation = %v, want 2 and %v", status.Servers.Total, status.Servers.Generation, test.generation)
+			}
+		})
+	}
+}
+
+func TestGetStatusInvalidBody(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<html>check status</html>"))
+	}))
+	defer server.Close()
+
+	if _, err := NewNginxClient(server.Client(), server.URL).GetStatus(context.Background()); err == nil {
+		t.Error("GetStatus() error = nil, want an error for the html format")
+	}
+}
@@ -0,0 +1,38 @@
+package collector
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// TimedCollector collects a collector and the time the collection took, so that a slow collector can be told apart
+// when several are registered. It implements prometheus.Collector interface.
+type TimedCollector struct {
+	collector prometheus.Collector
+	duration  *prometheus.Desc
+}
+
+// NewTimedCollector creates a TimedCollector that exports the duration of the collection of collector as
+// <namespace>_collector_duration_seconds with the name in the collector label.
+func NewTimedCollector(collector prometheus.Collector, namespace string, name string, constLabels map[string]string) *TimedCollector {
+	return &TimedCollector{
+		collector: collector,
+		duration: prometheus.NewDesc(prometheus.BuildFQName(namespace, "collector", "duration_seconds"), "Duration of the last collection of the collector in seconds",
+			nil, MergeLabels(constLabels, map[string]string{"collector": name})),
+	}
+}
+
+// Describe sends the descriptors of the collector and the duration metric to the provided channel.
+func (c *TimedCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+	ch <- c.duration
+}
+
+// Collect collects the collector and then sends the duration of the collection to the provided channel.
+func (c *TimedCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	c.collector.Collect(ch)
+	ch <- prometheus.MustNewConstMetric(c.duration,
+		prometheus.GaugeValue, time.Since(start).Seconds())
+}
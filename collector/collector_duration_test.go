@@ -0,0 +1,25 @@
+package collector
+
+import (
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestTimedCollector(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewPedanticRegistry()
+	for _, name := range []string{"error_log", "other_error_log"} {
+		timed := NewTimedCollector(NewNginxErrorLogCollector("nginx_"+name, nil, log.NewNopLogger()), "nginxexporter", name, nil)
+		if err := registry.Register(timed); err != nil {
+			t.Fatalf("Register() error = %v", err)
+		}
+	}
+
+	if n, err := testutil.GatherAndCount(registry, "nginxexporter_collector_duration_seconds"); err != nil || n != 2 {
+		t.Errorf("GatherAndCount() = %v, %v, want 2, nil", n, err)
+	}
+}
@@ -0,0 +1,64 @@
+package collector
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// The countries of the addresses that are not in the database, and of the countries that are not allowed.
+const (
+	unknownCountry = "unknown"
+	otherCountry   = "other"
+)
+
+// GeoIP looks up the countries of the client addresses in a MaxMind DB, e.g. GeoLite2-Country or GeoLite2-City.
+type GeoIP struct {
+	reader *maxminddb.Reader
+	// countries are the allowed ISO country codes, nil allows all the countries
+	countries map[string]bool
+}
+
+type geoIPRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+}
+
+// OpenGeoIP opens the MaxMind DB at path. If countries are given, the other countries are returned as other.
+func OpenGeoIP(path string, countries []string) (*GeoIP, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the GeoIP database: %w", err)
+	}
+
+	g := &GeoIP{reader: reader}
+	for _, country := range countries {
+		if country = strings.ToUpper(strings.TrimSpace(country)); country != "" {
+			if g.countries == nil {
+				g.countries = make(map[string]bool)
+			}
+			g.countries[country] = true
+		}
+	}
+	return g, nil
+}
+
+// Country returns the ISO country code of the address, unknown if the address is not in the database, or other if
+// the country is not allowed.
+func (g *GeoIP) Country(addr string) string {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return unknownCountry
+	}
+	var record geoIPRecord
+	if err := g.reader.Lookup(ip, &record); err != nil || record.Country.ISOCode == "" {
+		return unknownCountry
+	}
+	if g.countries != nil && !g.countries[record.Country.ISOCode] {
+		return otherCountry
+	}
+	return record.Country.ISOCode
+}
@@ -0,0 +1,98 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// writeTestGeoIPDatabase writes an IPv4 MaxMind DB with a single node: the addresses from 0.0.0.0/1 are in DE, the
+// others are not in the database.
+func writeTestGeoIPDatabase(t *testing.T) string {
+	t.Helper()
+
+	var db []byte
+	// the left record points to the data, the right record is the node count for not found
+	db = append(db, 0x00, 0x00, 0x11, 0x00, 0x00, 0x01)
+	db = append(db, make([]byte, 16)...)
+	db = append(db, 0xE1, 0x47)
+	db = append(db, "country"...)
+	db = append(db, 0xE1, 0x48)
+	db = append(db, "iso_code"...)
+	db = append(db, 0x42)
+	db = append(db, "DE"...)
+	db = append(db, "\xAB\xCD\xEFMaxMind.com"...)
+	db = append(db, 0xE3, 0x4A)
+	db = append(db, "node_count"...)
+	db = append(db, 0xC1, 0x01, 0x4B)
+	db = append(db, "record_size"...)
+	db = append(db, 0xA1, 0x18, 0x4A)
+	db = append(db, "ip_version"...)
+	db = append(db, 0xA1, 0x04)
+
+	path := filepath.Join(t.TempDir(), "country.mmdb")
+	if err := os.WriteFile(path, db, 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return path
+}
+
+func TestGeoIPCountry(t *testing.T) {
+	t.Parallel()
+
+	path := writeTestGeoIPDatabase(t)
+	tests := []struct {
+		name      string
+		countries []string
+		addr      string
+		want      string
+	}{
+		{name: "country", addr: "1.2.3.4", want: "DE"},
+		{name: "allowed country", countries: []string{"de", "FR"}, addr: "1.2.3.4", want: "DE"},
+		{name: "other country", countries: []string{"FR"}, addr: "1.2.3.4", want: "other"},
+		{name: "not in the database", addr: "200.0.0.1", want: "unknown"},
+		{name: "invalid address", addr: "-", want: "unknown"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			geoIP, err := OpenGeoIP(path, tt.countries)
+			if err != nil {
+				t.Fatalf("OpenGeoIP() error = %v", err)
+			}
+			if got := geoIP.Country(tt.addr); got != tt.want {
+				t.Errorf("Country(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNginxAccessLogCollectorGeoIP(t *testing.T) {
+	t.Parallel()
+
+	geoIP, err := OpenGeoIP(writeTestGeoIPDatabase(t), nil)
+	if err != nil {
+		t.Fatalf("OpenGeoIP() error = %v", err)
+	}
+	format, err := logformat.New(`$remote_addr $host "$request" $status`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.SetGeoIP(geoIP)
+	collector.HandleLine(`1.2.3.4 example.com "GET / HTTP/1.1" 200`)
+	collector.HandleLine(`200.0.0.1 example.com "GET / HTTP/1.1" 200`)
+
+	expected := `# HELP nginx_http_country_requests_total Total requests logged in the access log by the country of the client address
+# TYPE nginx_http_country_requests_total counter
+nginx_http_country_requests_total{country="DE",vhost="example.com"} 1
+nginx_http_country_requests_total{country="unknown",vhost="example.com"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_country_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
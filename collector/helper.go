@@ -1,6 +1,18 @@
 package collector
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -22,6 +34,86 @@ func newUpMetric(namespace string, constLabels map[string]string) prometheus.Gau
 	})
 }
 
+// MetricsNamespace is the namespace of the metrics about the exporter itself, e.g. the collector durations and the
+// scrape errors.
+const MetricsNamespace = "nginxexporter"
+
+// newScrapeErrorsMetric creates the counter of the failed scrapes of a collector. The counters of all the collectors
+// are exported as MetricsNamespace_scrape_errors_total with the name in the collector label, like the durations of
+// NewTimedCollector.
+func newScrapeErrorsMetric(name string, constLabels map[string]string) *prometheus.CounterVec {
+	return prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace:   MetricsNamespace,
+		Name:        "scrape_errors_total",
+		Help:        "Total failed metric scrapes of the collector by the reason of the error",
+		ConstLabels: MergeLabels(constLabels, map[string]string{"collector": name}),
+	}, []string{"reason"})
+}
+
+func newLastScrapeErrorMetric(namespace string, constLabels map[string]string) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "last_scrape_error"), "Reason of the error of the last metric scrape, exported only while the scrape fails", []string{"reason"}, constLabels)
+}
+
+// newLogHistogramVec creates a histogram of a log collector. A native histogram has sparse buckets with a growth factor
+// of 1.1 instead of the classic buckets, the resolution is reduced above 160 buckets.
+func newLogHistogramVec(opts prometheus.HistogramOpts, labelNames []string, native bool) *prometheus.HistogramVec {
+	if native {
+		opts.Buckets = nil
+		opts.NativeHistogramBucketFactor = 1.1
+		opts.NativeHistogramMaxBucketNumber = 160
+		opts.NativeHistogramMinResetDuration = time.Hour
+	}
+	return prometheus.NewHistogramVec(opts, labelNames)
+}
+
+// unexpectedStatus matches the errors of nginx-plus-go-client for the unexpected responses, which are not typed.
+var unexpectedStatus = regexp.MustCompile(`expected 200 response, got (\d{3})`)
+
+// classifyError returns the reason of an error of the clients: timeout, dns, tls, auth, http_5xx, http_status, parse,
+// connection or other.
+func classifyError(err error) string {
+	var (
+		statusErr  *client.StatusError
+		netErr     net.Error
+		dnsErr     *net.DNSError
+		opErr      *net.OpError
+		authErr    x509.UnknownAuthorityError
+		hostErr    x509.HostnameError
+		certErr    x509.CertificateInvalidError
+		recordErr  tls.RecordHeaderError
+		parseErr   *client.ParseError
+		syntaxErr  *json.SyntaxError
+		typeErr    *json.UnmarshalTypeError
+		statusCode int
+	)
+	if errors.As(err, &statusErr) {
+		statusCode = statusErr.StatusCode
+	} else if m := unexpectedStatus.FindStringSubmatch(err.Error()); m != nil {
+		statusCode, _ = strconv.Atoi(m[1])
+	}
+
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return "auth"
+	case statusCode >= http.StatusInternalServerError:
+		return "http_5xx"
+	case statusCode != 0:
+		return "http_status"
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.As(err, &authErr), errors.As(err, &hostErr), errors.As(err, &certErr), errors.As(err, &recordErr):
+		return "tls"
+	case errors.As(err, &parseErr), errors.As(err, &syntaxErr), errors.As(err, &typeErr):
+		return "parse"
+	case errors.As(err, &opErr):
+		return "connection"
+	default:
+		return "other"
+	}
+}
+
 // MergeLabels merges two maps of labels.
 func MergeLabels(a map[string]string, b map[string]string) map[string]string {
 	c := make(map[string]string)
@@ -1,8 +1,19 @@
 package collector
 
 import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/nginxinc/nginx-prometheus-exporter/client"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 )
 
 func TestMergeLabels(t *testing.T) {
@@ -39,3 +50,98 @@ func TestMergeLabels(t *testing.T) {
 		})
 	}
 }
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed to get stats: %w", &client.StatusError{StatusCode: 401}), "auth"},
+		{&client.StatusError{StatusCode: 502}, "http_5xx"},
+		{&client.StatusError{StatusCode: 404}, "http_status"},
+		{errors.New("failed to get stats: failed to get connections: expected 200 response, got 403. error.status=403"), "auth"},
+		{fmt.Errorf("failed to get http://nginx: %w", &net.DNSError{Err: "no such host", Name: "nginx"}), "dns"},
+		{fmt.Errorf("failed to get http://nginx: %w", context.DeadlineExceeded), "timeout"},
+		{fmt.Errorf("failed to get https://nginx: %w", x509.UnknownAuthorityError{}), "tls"},
+		{fmt.Errorf("failed to parse response body: %w", &client.ParseError{Err: errors.New("unexpected EOF")}), "parse"},
+		{fmt.Errorf("error unmarshaling response: %w", &json.SyntaxError{}), "parse"},
+		{fmt.Errorf("failed to get http://nginx: %w", &net.OpError{Op: "dial", Err: errors.New("connection refused")}), "connection"},
+		{errors.New("something else"), "other"},
+	}
+	for _, tt := range tests {
+		if got := classifyError(tt.err); got != tt.want {
+			t.Errorf("classifyError(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestNewLogHistogramVec(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		native      bool
+		wantBuckets int
+	}{
+		{name: "classic buckets", native: false, wantBuckets: len(prometheus.DefBuckets)},
+		{name: "native histogram", native: true, wantBuckets: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histogram := newLogHistogramVec(prometheus.HistogramOpts{
+				Name:    "http_request_duration_seconds",
+				Help:    "Duration of the requests",
+				Buckets: prometheus.DefBuckets,
+			}, []string{"vhost"}, tt.native)
+			histogram.WithLabelValues("example.com").Observe(0.012)
+
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(histogram)
+			families, err := registry.Gather()
+			if err != nil {
+				t.Fatalf("Gather() error = %v", err)
+			}
+			got := families[0].GetMetric()[0].GetHistogram()
+			if native := got.Schema != nil; native != tt.native {
+				t.Errorf("newLogHistogramVec() native = %v, want %v", native, tt.native)
+			}
+			if n := len(got.GetBucket()); n != tt.wantBuckets {
+				t.Errorf("newLogHistogramVec() classic buckets = %v, want %v", n, tt.wantBuckets)
+			}
+		})
+	}
+}
+
+func TestNewScrapeErrorsMetric(t *testing.T) {
+	t.Parallel()
+
+	scrapeErrors := newScrapeErrorsMetric("stub_status", map[string]string{"instance": "a"})
+	scrapeErrors.WithLabelValues("timeout").Inc()
+
+	const want = `# HELP nginxexporter_scrape_errors_total Total failed metric scrapes of the collector by the reason of the error
+# TYPE nginxexporter_scrape_errors_total counter
+nginxexporter_scrape_errors_total{collector="stub_status",instance="a",reason="timeout"} 1
+`
+	if err := testutil.CollectAndCompare(scrapeErrors, strings.NewReader(want)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewLastScrapeErrorMetric(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		namespace string
+		want      string
+	}{
+		{namespace: "nginx", want: `Desc{fqName: "nginx_last_scrape_error", help: "Reason of the error of the last metric scrape, exported only while the scrape fails", constLabels: {}, variableLabels: {reason}}`},
+		{namespace: "", want: `Desc{fqName: "last_scrape_error", help: "Reason of the error of the last metric scrape, exported only while the scrape fails", constLabels: {}, variableLabels: {reason}}`},
+	}
+	for _, tt := range tests {
+		if got := newLastScrapeErrorMetric(tt.namespace, nil).String(); got != tt.want {
+			t.Errorf("newLastScrapeErrorMetric(%q) = %v, want %v", tt.namespace, got, tt.want)
+		}
+	}
+}
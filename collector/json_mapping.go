@@ -0,0 +1,271 @@
+package collector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/jsonstatus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"gopkg.in/yaml.v2"
+)
+
+// JSONMapping maps the values of a JSON status page to metrics.
+type JSONMapping struct {
+	Metrics []JSONMetric `yaml:"metrics"`
+}
+
+// JSONMetric maps the values found at Path to a metric. Path is a dot separated list of object keys and array
+// indexes, e.g. zones.main.requests. A * matches every key of an object or every index of an array, the matched
+// keys and indexes become the values of Labels in the order of the wildcards.
+type JSONMetric struct {
+	Name        string            `yaml:"name"`
+	Help        string            `yaml:"help"`
+	Type        string            `yaml:"type"`
+	Path        string            `yaml:"path"`
+	Labels      []string          `yaml:"labels"`
+	ConstLabels map[string]string `yaml:"const_labels"`
+}
+
+// LoadJSONMapping reads a JSONMapping from a YAML file.
+func LoadJSONMapping(path string) (*JSONMapping, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JSON mapping: %w", err)
+	}
+	var mapping JSONMapping
+	if err := yaml.UnmarshalStrict(data, &mapping); err != nil {
+		return nil, fmt.Errorf("failed to parse the JSON mapping %v: %w", path, err)
+	}
+	return &mapping, nil
+}
+
+type jsonMetric struct {
+	desc      *prometheus.Desc
+	valueType prometheus.ValueType
+	path      []string
+}
+
+// JSONMappingCollector collects the metrics mapped from a custom JSON status page. It implements prometheus.Collector interface.
+type JSONMappingCollector struct {
+	nginxClient  *jsonstatus.NginxClient
+	metrics      []jsonMetric
+	upMetric     prometheus.Gauge
+	scrapeErrors *prometheus.CounterVec
+	lastError    *prometheus.Desc
+	mutex        sync.Mutex
+	logger       log.Logger
+}
+
+// NewJSONMappingCollector creates a JSONMappingCollector. The mapped metrics, the up and the last scrape error metrics
+// are prefixed with namespace_json, so that the mapped metrics cannot collide with the metrics of the other collectors.
+// An error is returned if the mapping is invalid.
+func NewJSONMappingCollector(nginxClient *jsonstatus.NginxClient, namespace string, mapping *JSONMapping, constLabels map[string]string, logger log.Logger) (*JSONMappingCollector, error) {
+	namespace += "_json"
+	metrics := make([]jsonMetric, 0, len(mapping.Metrics))
+	// the names of the up and the last scrape error metrics cannot be mapped
+	names := map[string]bool{
+		prometheus.BuildFQName(namespace, "", "up"):                true,
+		prometheus.BuildFQName(namespace, "", "last_scrape_error"): true,
+	}
+	for _, m := range mapping.Metrics {
+		name := prometheus.BuildFQName(namespace, "", m.Name)
+		if m.Name == "" || !model.IsValidMetricName(model.LabelValue(name)) {
+			return nil, fmt.Errorf("invalid metric name %q", m.Name)
+		}
+		if names[name] {
+			return nil, fmt.Errorf("metric %q is mapped more than once or is the name of a metric of the collector", m.Name)
+		}
+		names[name] = true
+
+		var valueType prometheus.ValueType
+		switch m.Type {
+		case "", "gauge":
+			valueType = prometheus.GaugeValue
+		case "counter":
+			valueType = prometheus.CounterValue
+		default:
+			return nil, fmt.Errorf("invalid type %q of metric %q, the supported types are gauge and counter", m.Type, m.Name)
+		}
+
+		if m.Path == "" {
+			return nil, fmt.Errorf("the path of metric %q is not set", m.Name)
+		}
+		path := strings.Split(m.Path, ".")
+		wildcards := 0
+		for _, segment := range path {
+			if segment == "*" {
+				wildcards++
+			}
+		}
+		if wildcards != len(m.Labels) {
+			return nil, fmt.Errorf("the path of metric %q has %v wildcards, but %v labels are set", m.Name, wildcards, len(m.Labels))
+		}
+		for _, label := range m.Labels {
+			if !model.LabelName(label).IsValid() {
+				return nil, fmt.Errorf("invalid label name %q of metric %q", label, m.Name)
+			}
+		}
+
+		help := m.Help
+		if help == "" {
+			help = fmt.Sprintf("Value of %v of the JSON status page", m.Path)
+		}
+		metrics = append(metrics, jsonMetric{
+			desc:      prometheus.NewDesc(name, help, m.Labels, MergeLabels(constLabels, m.ConstLabels)),
+			valueType: valueType,
+			path:      path,
+		})
+	}
+
+	return &JSONMappingCollector{
+		nginxClient:  nginxClient,
+		metrics:      metrics,
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("json", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
+		logger:       logger,
+	}, nil
+}
+
+// Describe sends the super-set of all possible descriptors of the mapped metrics
+// to the provided channel.
+func (c *JSONMappingCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
+
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+// Collect fetches the JSON status page and sends the mapped metrics to the provided channel.
+func (c *JSONMappingCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	status, err := c.nginxClient.GetStatus(context.Background())
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
+		level.Error(c.logger).Log("msg", "Error getting JSON status", "error", err.Error())
+		return
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
+
+	for _, m := range c.metrics {
+		m := m
+		walkJSONPath(status, m.path, nil, func(node interface{}, labelValues []string) {
+			value, ok := jsonValue(node)
+			if !ok {
+				level.Debug(c.logger).Log("msg", "Skipping a non numeric JSON value", "metric", m.desc.String(), "value", fmt.Sprint(node))
+				return
+			}
+			ch <- prometheus.MustNewConstMetric(m.desc, m.valueType, value, labelValues...)
+		})
+	}
+}
+
+// walkJSONPath calls found for every node matched by path with the keys and indexes matched by the wildcards.
+func walkJSONPath(node interface{}, path []string, labelValues []string, found func(node interface{}, labelValues []string)) {
+	if len(path) == 0 {
+		found(node, labelValues)
+		return
+	}
+
+	segment, rest := path[0], path[1:]
+	switch n := node.(type) {
+	case map[string]interface{}:
+		if segment != "*" {
+			if child, ok := n[segment]; ok {
+				walkJSONPath(child, rest, labelValues, found)
+			}
+			return
+		}
+		keys := make([]string, 0, len(n))
+		for key := range n {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			walkJSONPath(n[key], rest, append(labelValues[:len(labelValues):len(labelValues)], key), found)
+		}
+	case []interface{}:
+		if segment != "*" {
+			if i, err := strconv.Atoi(segment); err == nil && i >= 0 && i < len(n) {
+				walkJSONPath(n[i], rest, labelValues, found)
+			}
+			return
+		}
+		for i, child := range n {
+			walkJSONPath(child, rest, append(labelValues[:len(labelValues):len(labelValues)], strconv.Itoa(i)), found)
+		}
+	}
+}
+
+// jsonValue converts a number, a boolean or a string holding a number to a metric value.
+func jsonValue(node interface{}) (float64, bool) {
+	switch v := node.(type) {
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case bool:
+		if v {
+			return 1.0, true
+		}
+		return 0.0, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// newJSONMappingFactory registers the flags of the custom JSON status page and of its mapping.
+func newJSONMappingFactory() Factory {
+	var uri *string
+	var mappingPath *string
+	return Factory{
+		Name: "json",
+		Flags: func(app *kingpin.Application) {
+			uri = app.Flag("nginx.json-status-uri", "A URI or unix domain socket path of a custom JSON status page, e.g. published with njs. When set, the values of the page are exported as the metrics of the JSON mapping.").Default("").Envar("JSON_STATUS_URI").String()
+			mappingPath = app.Flag("nginx.json-mapping", "Path to the YAML file that maps the values of the JSON status page to metrics. Required with -nginx.json-status-uri.").Default("").Envar("JSON_MAPPING").String()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if *uri == "" {
+				return nil, nil
+			}
+			mapping, err := LoadJSONMapping(*mappingPath)
+			if err != nil {
+				return nil, err
+			}
+			httpClient, scrapeURI, err := env.NewHTTPClient(*uri, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the JSON status address %v: %w", *uri, err)
+			}
+			c, err := NewJSONMappingCollector(jsonstatus.NewNginxClient(httpClient, scrapeURI), "nginx", mapping, env.ConstLabels, env.Logger)
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON mapping %v: %w", *mappingPath, err)
+			}
+			return c, nil
+		},
+	}
+}
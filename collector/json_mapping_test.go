@@ -0,0 +1,112 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/jsonstatus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestJSONMappingCollector(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{
+			"requests": 12,
+			"ready": true,
+			"zones": {"main": {"hits": "3"}, "api": {"hits": 5}, "static": {"hits": "n/a"}},
+			"backends": [{"rtt": 0.5}, {"rtt": 1.5}]
+		}`))
+	}))
+	defer server.Close()
+
+	config := filepath.Join(t.TempDir(), "mapping.yml")
+	err := os.WriteFile(config, []byte(`
+metrics:
+- name: njs_requests_total
+  help: Total requests counted by njs
+  type: counter
+  path: requests
+- name: njs_ready
+  path: ready
+  const_labels:
+    source: njs
+- name: njs_zone_hits
+  type: counter
+  path: zones.*.hits
+  labels: [zone]
+- name: njs_backend_rtt_seconds
+  path: backends.*.rtt
+  labels: [backend]
+- name: njs_missing
+  path: zones.missing.hits
+`), 0o600)
+	if err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	mapping, err := LoadJSONMapping(config)
+	if err != nil {
+		t.Fatalf("LoadJSONMapping() error = %v", err)
+	}
+
+	collector, err := NewJSONMappingCollector(jsonstatus.NewNginxClient(server.Client(), server.URL), "nginx", mapping, nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewJSONMappingCollector() error = %v", err)
+	}
+
+	expected := `# HELP nginx_json_up Status of the last metric scrape
+# TYPE nginx_json_up gauge
+nginx_json_up 1
+# HELP nginx_json_njs_backend_rtt_seconds Value of backends.*.rtt of the JSON status page
+# TYPE nginx_json_njs_backend_rtt_seconds gauge
+nginx_json_njs_backend_rtt_seconds{backend="0"} 0.5
+nginx_json_njs_backend_rtt_seconds{backend="1"} 1.5
+# HELP nginx_json_njs_ready Value of ready of the JSON status page
+# TYPE nginx_json_njs_ready gauge
+nginx_json_njs_ready{source="njs"} 1
+# HELP nginx_json_njs_requests_total Total requests counted by njs
+# TYPE nginx_json_njs_requests_total counter
+nginx_json_njs_requests_total 12
+# HELP nginx_json_njs_zone_hits Value of zones.*.hits of the JSON status page
+# TYPE nginx_json_njs_zone_hits counter
+nginx_json_njs_zone_hits{zone="api"} 5
+nginx_json_njs_zone_hits{zone="main"} 3
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNewJSONMappingCollectorInvalidMapping(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		metric JSONMetric
+	}{
+		{name: "no name", metric: JSONMetric{Path: "requests"}},
+		{name: "invalid name", metric: JSONMetric{Name: "njs-requests", Path: "requests"}},
+		{name: "invalid type", metric: JSONMetric{Name: "requests", Type: "histogram", Path: "requests"}},
+		{name: "no path", metric: JSONMetric{Name: "requests"}},
+		{name: "missing label", metric: JSONMetric{Name: "hits", Path: "zones.*.hits"}},
+		{name: "invalid label", metric: JSONMetric{Name: "hits", Path: "zones.*.hits", Labels: []string{"zone-name"}}},
+		{name: "name of the up metric", metric: JSONMetric{Name: "up", Path: "ready"}},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			mapping := &JSONMapping{Metrics: []JSONMetric{test.metric}}
+			if _, err := NewJSONMappingCollector(nil, "nginx", mapping, nil, log.NewNopLogger()); err == nil {
+				t.Errorf("NewJSONMappingCollector() error = nil, want an error for %+v", test.metric)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package collector
+
+import (
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// LogFiles are the log files followed by a tail.Tailer or a tail.Glob.
+type LogFiles interface {
+	FileStats() map[string]tail.Stats
+}
+
+// LogFileCollector collects the counters of tailed log files, so that a rotation that loses lines can be noticed.
+// It implements prometheus.Collector interface.
+type LogFileCollector struct {
+	files   LogFiles
+	metrics map[string]*prometheus.Desc
+}
+
+// NewLogFileCollector creates a LogFileCollector for the files. The path of a file is exported as the path label.
+func NewLogFileCollector(files LogFiles, namespace string, constLabels map[string]string) *LogFileCollector {
+	return &LogFileCollector{
+		files: files,
+		metrics: map[string]*prometheus.Desc{
+			"reopens":     prometheus.NewDesc(prometheus.BuildFQName(namespace, "log_file", "reopens_total"), "Total times the log file was reopened after it was replaced", []string{"path"}, constLabels),
+			"truncations": prometheus.NewDesc(prometheus.BuildFQName(namespace, "log_file", "truncations_total"), "Total times the log file was truncated", []string{"path"}, constLabels),
+			"lost_lines":  prometheus.NewDesc(prometheus.BuildFQName(namespace, "log_file", "lost_lines_total"), "Total lines dropped because they were incomplete when the log file was replaced or truncated, or longer than 1 MiB", []string{"path"}, constLabels),
+		},
+	}
+}
+
+// Describe sends no descriptors, so the collector is unchecked: the collectors of the access log and of the error log
+// export the same metrics for different paths and both must be registered.
+func (c *LogFileCollector) Describe(ch chan<- *prometheus.Desc) {
+}
+
+// Collect sends the counters of the tailed log files to the provided channel.
+func (c *LogFileCollector) Collect(ch chan<- prometheus.Metric) {
+	for path, stats := range c.files.FileStats() {
+		ch <- prometheus.MustNewConstMetric(c.metrics["reopens"],
+			prometheus.CounterValue, float64(stats.Reopens), path)
+		ch <- prometheus.MustNewConstMetric(c.metrics["truncations"],
+			prometheus.CounterValue, float64(stats.Truncations), path)
+		ch <- prometheus.MustNewConstMetric(c.metrics["lost_lines"],
+			prometheus.CounterValue, float64(stats.LostLines), path)
+	}
+}
@@ -0,0 +1,48 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLogFileCollector(t *testing.T) {
+	t.Parallel()
+
+	tailer := tail.NewTailer("/var/log/nginx/access.log", time.Second, log.NewNopLogger())
+	collector := NewLogFileCollector(tailer, "nginx", map[string]string{"instance": "a"})
+
+	expected := `# HELP nginx_log_file_lost_lines_total Total lines dropped because they were incomplete when the log file was replaced or truncated, or longer than 1 MiB
+# TYPE nginx_log_file_lost_lines_total counter
+nginx_log_file_lost_lines_total{instance="a",path="/var/log/nginx/access.log"} 0
+# HELP nginx_log_file_reopens_total Total times the log file was reopened after it was replaced
+# TYPE nginx_log_file_reopens_total counter
+nginx_log_file_reopens_total{instance="a",path="/var/log/nginx/access.log"} 0
+# HELP nginx_log_file_truncations_total Total times the log file was truncated
+# TYPE nginx_log_file_truncations_total counter
+nginx_log_file_truncations_total{instance="a",path="/var/log/nginx/access.log"} 0
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestLogFileCollectorsOfSeveralLogs(t *testing.T) {
+	t.Parallel()
+
+	registry := prometheus.NewPedanticRegistry()
+	for _, path := range []string{"/var/log/nginx/access.log", "/var/log/nginx/error.log"} {
+		tailer := tail.NewTailer(path, time.Second, log.NewNopLogger())
+		if err := registry.Register(NewLogFileCollector(tailer, "nginx", nil)); err != nil {
+			t.Fatalf("Register() of %v error = %v", path, err)
+		}
+	}
+	if count, err := testutil.GatherAndCount(registry, "nginx_log_file_reopens_total"); err != nil || count != 2 {
+		t.Errorf("GatherAndCount() = %v, %v, want 2, nil", count, err)
+	}
+}
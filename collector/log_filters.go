@@ -0,0 +1,106 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// LogFilters pick the access log lines that update the metrics, e.g. to drop the health checks, and the lines that
+// are observed by the histograms, e.g. only the 5xx responses.
+type LogFilters struct {
+	LogFilter `yaml:",inline"`
+	// Histograms filter the lines observed by the histograms among the lines picked by the LogFilter.
+	Histograms LogFilter `yaml:"histograms"`
+}
+
+// LogFilter picks a line if it matches any of the Include rules, or if there are none, and none of the Exclude rules.
+type LogFilter struct {
+	Include []LogFilterRule `yaml:"include"`
+	Exclude []LogFilterRule `yaml:"exclude"`
+}
+
+// LogFilterRule matches a line if the regular expressions of all the given fields match the vhost, the path without
+// the query string and the status.
+type LogFilterRule struct {
+	Vhost  string `yaml:"vhost"`
+	Path   string `yaml:"path"`
+	Status string `yaml:"status"`
+
+	vhost  *regexp.Regexp
+	path   *regexp.Regexp
+	status *regexp.Regexp
+}
+
+// LoadLogFilters reads LogFilters from a YAML file.
+func LoadLogFilters(path string) (*LogFilters, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the log filters: %w", err)
+	}
+	var filters LogFilters
+	if err := yaml.UnmarshalStrict(data, &filters); err != nil {
+		return nil, fmt.Errorf("failed to parse the log filters %v: %w", path, err)
+	}
+	for _, rules := range [][]LogFilterRule{filters.Include, filters.Exclude, filters.Histograms.Include, filters.Histograms.Exclude} {
+		for i := range rules {
+			if err := rules[i].compile(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return &filters, nil
+}
+
+func (r *LogFilterRule) compile() error {
+	fields := []struct {
+		name    string
+		pattern string
+		re      **regexp.Regexp
+	}{
+		{"vhost", r.Vhost, &r.vhost},
+		{"path", r.Path, &r.path},
+		{"status", r.Status, &r.status},
+	}
+	for _, field := range fields {
+		if field.pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(field.pattern)
+		if err != nil {
+			return fmt.Errorf("invalid %v pattern of the log filter: %w", field.name, err)
+		}
+		*field.re = re
+	}
+	return nil
+}
+
+// Allows reports whether the filter picks the line with the vhost, path and status.
+func (f *LogFilter) Allows(vhost string, path string, status string) bool {
+	path, _, _ = strings.Cut(path, "?")
+	included := len(f.Include) == 0
+	for i := range f.Include {
+		if f.Include[i].matches(vhost, path, status) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+	for i := range f.Exclude {
+		if f.Exclude[i].matches(vhost, path, status) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *LogFilterRule) matches(vhost string, path string, status string) bool {
+	return (r.vhost == nil || r.vhost.MatchString(vhost)) &&
+		(r.path == nil || r.path.MatchString(path)) &&
+		(r.status == nil || r.status.MatchString(status))
+}
@@ -0,0 +1,111 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func loadTestLogFilters(t *testing.T, data string) (*LogFilters, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "filters.yml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return LoadLogFilters(path)
+}
+
+func TestLogFilterAllows(t *testing.T) {
+	t.Parallel()
+
+	filters, err := loadTestLogFilters(t, `include:
+  - vhost: ^example\.com$
+  - vhost: ^example\.org$
+    status: ^5
+exclude:
+  - path: ^/healthz$
+`)
+	if err != nil {
+		t.Fatalf("LoadLogFilters() error = %v", err)
+	}
+
+	tests := []struct {
+		vhost  string
+		path   string
+		status string
+		want   bool
+	}{
+		{vhost: "example.com", path: "/", status: "200", want: true},
+		{vhost: "example.com", path: "/healthz", status: "200", want: false},
+		{vhost: "example.com", path: "/healthz?full=1", status: "200", want: false},
+		{vhost: "example.org", path: "/", status: "200", want: false},
+		{vhost: "example.org", path: "/", status: "502", want: true},
+		{vhost: "example.net", path: "/", status: "200", want: false},
+	}
+	for _, tt := range tests {
+		if got := filters.Allows(tt.vhost, tt.path, tt.status); got != tt.want {
+			t.Errorf("Allows(%q, %q, %q) = %v, want %v", tt.vhost, tt.path, tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestLoadLogFiltersInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := loadTestLogFilters(t, "exclude:\n  - path: \"(\"\n"); err == nil {
+		t.Error("LoadLogFilters() of an invalid pattern error = nil, want an error")
+	}
+	if _, err := loadTestLogFilters(t, "histograms:\n  include:\n    - code: ^5\n"); err == nil {
+		t.Error("LoadLogFilters() of an unknown key error = nil, want an error")
+	}
+}
+
+func TestNginxAccessLogCollectorLogFilters(t *testing.T) {
+	t.Parallel()
+
+	filters, err := loadTestLogFilters(t, "exclude:\n  - path: ^/healthz$\nhistograms:\n  include:\n    - status: ^5\n")
+	if err != nil {
+		t.Fatalf("LoadLogFilters() error = %v", err)
+	}
+	format, err := logformat.New(`$host "$request" $status $request_time`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.SetLogFilters(filters)
+	collector.HandleLine(`example.com "GET /healthz HTTP/1.1" 200 0.001`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 0.010`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 502 2.000`)
+
+	expected := `# HELP nginx_http_responses_total Total responses logged in the access log
+# TYPE nginx_http_responses_total counter
+nginx_http_responses_total{method="GET",status="200",vhost="example.com"} 1
+nginx_http_responses_total{method="GET",status="502",vhost="example.com"} 1
+# HELP nginx_http_request_duration_seconds Duration of the requests logged in the access log
+# TYPE nginx_http_request_duration_seconds histogram
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.005"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.01"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.025"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.05"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.1"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.25"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="0.5"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="1"} 0
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="2.5"} 1
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="5"} 1
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="10"} 1
+nginx_http_request_duration_seconds_bucket{vhost="example.com",le="+Inf"} 1
+nginx_http_request_duration_seconds_sum{vhost="example.com"} 2
+nginx_http_request_duration_seconds_count{vhost="example.com"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"nginx_http_responses_total", "nginx_http_request_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
@@ -11,11 +11,14 @@ import (
 
 // NginxCollector collects NGINX metrics. It implements prometheus.Collector interface.
 type NginxCollector struct {
-	nginxClient *client.NginxClient
-	metrics     map[string]*prometheus.Desc
-	upMetric    prometheus.Gauge
-	mutex       sync.Mutex
-	logger      log.Logger
+	nginxClient      *client.NginxClient
+	metrics          map[string]*prometheus.Desc
+	upMetric         prometheus.Gauge
+	scrapeErrors     *prometheus.CounterVec
+	lastError        *prometheus.Desc
+	connectionsLimit uint64
+	mutex            sync.Mutex
+	logger           log.Logger
 }
 
 // NewNginxCollector creates an NginxCollector.
@@ -31,15 +34,28 @@ func NewNginxCollector(nginxClient *client.NginxClient, namespace string, constL
 			"connections_writing":  newGlobalMetric(namespace, "connections_writing", "Connections where NGINX is writing the response back to the client", constLabels),
 			"connections_waiting":  newGlobalMetric(namespace, "connections_waiting", "Idle client connections", constLabels),
 			"http_requests_total":  newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+			"connections_limit":    newGlobalMetric(namespace, "connections_limit", "Maximum number of client connections set in the configuration", constLabels),
 		},
-		upMetric: newUpMetric(namespace, constLabels),
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("stub_status", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
 	}
 }
 
+// SetConnectionsLimit sets the maximum number of client connections, i.e. the worker_connections multiplied by the number
+// of the worker processes. The limit is not exported if it is 0.
+func (c *NginxCollector) SetConnectionsLimit(limit uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.connectionsLimit = limit
+}
+
 // Describe sends the super-set of all possible descriptors of NGINX metrics
 // to the provided channel.
 func (c *NginxCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
 
 	for _, m := range c.metrics {
 		ch <- m
@@ -55,12 +71,18 @@ func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
 		level.Error(c.logger).Log("msg", "Error getting stats", "error", err.Error())
 		return
 	}
 
 	c.upMetric.Set(nginxUp)
 	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
 
 	ch <- prometheus.MustNewConstMetric(c.metrics["connections_active"],
 		prometheus.GaugeValue, float64(stats.Connections.Active))
@@ -76,4 +98,8 @@ func (c *NginxCollector) Collect(ch chan<- prometheus.Metric) {
 		prometheus.GaugeValue, float64(stats.Connections.Waiting))
 	ch <- prometheus.MustNewConstMetric(c.metrics["http_requests_total"],
 		prometheus.CounterValue, float64(stats.Requests))
+	if c.connectionsLimit > 0 {
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections_limit"],
+			prometheus.GaugeValue, float64(c.connectionsLimit))
+	}
 }
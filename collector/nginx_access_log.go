@@ -0,0 +1,622 @@
+package collector
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/nginxinc/nginx-prometheus-exporter/syslog"
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// httpMethods are the methods exported as the method label, other methods are exported as other
+// so that malformed requests do not create new series.
+var httpMethods = map[string]bool{
+	"GET": true, "HEAD": true, "POST": true, "PUT": true, "DELETE": true,
+	"CONNECT": true, "OPTIONS": true, "TRACE": true, "PATCH": true,
+}
+
+// cacheStatuses are the values of $upstream_cache_status exported as the status label in lower case.
+var cacheStatuses = map[string]bool{
+	"MISS": true, "BYPASS": true, "EXPIRED": true, "STALE": true,
+	"UPDATING": true, "REVALIDATED": true, "HIT": true,
+}
+
+// NginxAccessLogCollector collects request metrics from the lines of an nginx access log written in a log format.
+// It implements prometheus.Collector interface.
+type NginxAccessLogCollector struct {
+	format            *logformat.Format
+	filters           *LogFilters
+	responses         *prometheus.CounterVec
+	requestDuration   *prometheus.HistogramVec
+	requestSize       *prometheus.HistogramVec
+	responseSize      *prometheus.HistogramVec
+	receivedBytes     *prometheus.CounterVec
+	sentBytes         *prometheus.CounterVec
+	pathTemplates     *PathTemplates
+	pathRequests      *prometheus.CounterVec
+	pathReceivedBytes *prometheus.CounterVec
+	pathSentBytes     *prometheus.CounterVec
+	geoIP             *GeoIP
+	countryRequests   *prometheus.CounterVec
+	userAgentClasses  *UserAgentClasses
+	userAgentRequests *prometheus.CounterVec
+	cacheResponses    *prometheus.CounterVec
+	upstreamResponses *prometheus.CounterVec
+	upstreamTimeouts  *prometheus.CounterVec
+	upstreamRetries   *prometheus.CounterVec
+	upstreamDuration  *prometheus.HistogramVec
+	unparsedLines     prometheus.Counter
+	lines             prometheus.Counter
+	sampledLines      prometheus.Counter
+	sampleRatio       float64
+	lineCount         atomic.Uint64
+	logger            log.Logger
+}
+
+type accessLogEntry struct {
+	vhost        string
+	method       string
+	status       string
+	duration     string
+	requestSize  string
+	responseSize string
+	path         string
+	remoteAddr   string
+	userAgent    string
+	cacheStatus  string
+	// upstreamAddr, upstreamStatus and upstreamResponseTime list the tries of the upstream servers
+	upstreamAddr         string
+	upstreamStatus       string
+	upstreamResponseTime string
+}
+
+// upstreamTry is a request to an upstream server, retried if nginx passed the request to the next server afterwards.
+type upstreamTry struct {
+	server       string
+	status       string
+	responseTime string
+	retried      bool
+}
+
+// NewNginxAccessLogCollector creates an NginxAccessLogCollector for the lines of the log format. The histograms are
+// only exported if the format has their variables: $request_time, $request_length, $bytes_sent or $body_bytes_sent and
+// $upstream_addr with $upstream_response_time.
+// With nativeHistograms, they are native histograms instead of histograms with classic buckets.
+func NewNginxAccessLogCollector(format *logformat.Format, nativeHistograms bool, namespace string, constLabels map[string]string, logger log.Logger) *NginxAccessLogCollector {
+	sizeBuckets := prometheus.ExponentialBuckets(100, 10, 6)
+	return &NginxAccessLogCollector{
+		format:      format,
+		logger:      logger,
+		sampleRatio: 1,
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_responses_total",
+			Help:        "Total responses logged in the access log",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "method", "status"}),
+		requestDuration: newLogHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "http_request_duration_seconds",
+			Help:        "Duration of the requests logged in the access log",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
+		}, []string{"vhost"}, nativeHistograms),
+		requestSize: newLogHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "http_request_size_bytes",
+			Help:        "Size of the requests logged in the access log, including the request line and the headers",
+			Buckets:     sizeBuckets,
+			ConstLabels: constLabels,
+		}, []string{"vhost"}, nativeHistograms),
+		responseSize: newLogHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "http_response_size_bytes",
+			Help:        "Size of the responses logged in the access log",
+			Buckets:     sizeBuckets,
+			ConstLabels: constLabels,
+		}, []string{"vhost"}, nativeHistograms),
+		receivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_received_bytes_total",
+			Help:        "Total bytes of the requests logged in the access log",
+			ConstLabels: constLabels,
+		}, []string{"vhost"}),
+		sentBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_sent_bytes_total",
+			Help:        "Total bytes of the responses logged in the access log",
+			ConstLabels: constLabels,
+		}, []string{"vhost"}),
+		pathRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_path_requests_total",
+			Help:        "Total requests logged in the access log by the path template",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "path"}),
+		pathReceivedBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_path_received_bytes_total",
+			Help:        "Total bytes of the requests logged in the access log by the path template",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "path"}),
+		pathSentBytes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_path_sent_bytes_total",
+			Help:        "Total bytes of the responses logged in the access log by the path template",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "path"}),
+		countryRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_country_requests_total",
+			Help:        "Total requests logged in the access log by the country of the client address",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "country"}),
+		userAgentRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_user_agent_requests_total",
+			Help:        "Total requests logged in the access log by the class of the user agent",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "class"}),
+		cacheResponses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_cache_responses_total",
+			Help:        "Total responses logged in the access log by the cache status",
+			ConstLabels: constLabels,
+		}, []string{"vhost", "status"}),
+		upstreamResponses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "upstream_server_responses_total",
+			Help:        "Total responses of the upstream servers logged in the access log by the status class",
+			ConstLabels: constLabels,
+		}, []string{"server", "code"}),
+		upstreamTimeouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "upstream_server_timeouts_total",
+			Help:        "Total tries of the upstream servers logged with the 504 status, e.g. when the server timed out",
+			ConstLabels: constLabels,
+		}, []string{"server"}),
+		upstreamRetries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "upstream_server_retries_total",
+			Help:        "Total tries of the upstream servers after which the request was passed to the next server",
+			ConstLabels: constLabels,
+		}, []string{"server"}),
+		upstreamDuration: newLogHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "upstream_server_response_duration_seconds",
+			Help:        "Duration of the tries of the upstream servers logged in the access log, from $upstream_response_time",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
+		}, []string{"server"}, nativeHistograms),
+		unparsedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "access_log_unparsed_lines_total",
+			Help:        "Total access log lines that could not be parsed",
+			ConstLabels: constLabels,
+		}),
+		lines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "access_log_lines_total",
+			Help:        "Total access log lines read, including the lines skipped by the sampling",
+			ConstLabels: constLabels,
+		}),
+		sampledLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "access_log_sampled_lines_total",
+			Help:        "Total access log lines picked by the sampling and parsed",
+			ConstLabels: constLabels,
+		}),
+	}
+}
+
+// SetPathTemplates enables the metrics by the path template. It must be called before the first line is handled.
+func (c *NginxAccessLogCollector) SetPathTemplates(templates *PathTemplates) {
+	c.pathTemplates = templates
+}
+
+// SetGeoIP enables the requests by the country of $remote_addr. It must be called before the first line is handled.
+func (c *NginxAccessLogCollector) SetGeoIP(geoIP *GeoIP) {
+	c.geoIP = geoIP
+}
+
+// SetUserAgentClasses enables the requests by the class of $http_user_agent. It must be called before the first line
+// is handled.
+func (c *NginxAccessLogCollector) SetUserAgentClasses(classes *UserAgentClasses) {
+	c.userAgentClasses = classes
+}
+
+// SetSampleRatio parses only the ratio of the lines, e.g. 0.1 for every tenth line, to save the CPU on busy hosts. The
+// counters are scaled by 1/ratio to estimate the totals, the histograms only observe the sampled lines. It must be
+// called before the first line is handled.
+func (c *NginxAccessLogCollector) SetSampleRatio(ratio float64) {
+	c.sampleRatio = ratio
+}
+
+// SetLogFilters picks the lines that update the metrics and the lines observed by the histograms. It must be called
+// before the first line is handled.
+func (c *NginxAccessLogCollector) SetLogFilters(filters *LogFilters) {
+	c.filters = filters
+}
+
+// HandleLine updates the metrics with a line of the access log.
+func (c *NginxAccessLogCollector) HandleLine(line string) {
+	c.HandleVhostLine("", line)
+}
+
+// HandleVhostLine updates the metrics with a line of the access log of a vhost, e.g. when every site is logged to a
+// separate file. The vhost is used when the format has no $server_name or $host.
+func (c *NginxAccessLogCollector) HandleVhostLine(vhost string, line string) {
+	c.lines.Inc()
+	if !c.sampled(c.lineCount.Add(1)) {
+		return
+	}
+	c.sampledLines.Inc()
+	weight := 1 / c.sampleRatio
+
+	entry, err := c.parseLine(line)
+	if err != nil {
+		c.unparsedLines.Add(weight)
+		level.Debug(c.logger).Log("msg", "Error parsing access log line", "line", line, "error", err.Error())
+		return
+	}
+	if entry.vhost == "" {
+		entry.vhost = vhost
+	}
+	observe := true
+	if c.filters != nil {
+		if !c.filters.Allows(entry.vhost, entry.path, entry.status) {
+			return
+		}
+		observe = c.filters.Histograms.Allows(entry.vhost, entry.path, entry.status)
+	}
+
+	c.responses.WithLabelValues(entry.vhost, entry.method, entry.status).Add(weight)
+	if duration, ok := parseLogNumber(entry.duration); ok && observe {
+		c.requestDuration.WithLabelValues(entry.vhost).Observe(duration)
+	}
+	requestSize, hasRequestSize := parseLogNumber(entry.requestSize)
+	if hasRequestSize {
+		if observe {
+			c.requestSize.WithLabelValues(entry.vhost).Observe(requestSize)
+		}
+		c.receivedBytes.WithLabelValues(entry.vhost).Add(requestSize * weight)
+	}
+	responseSize, hasResponseSize := parseLogNumber(entry.responseSize)
+	if hasResponseSize {
+		if observe {
+			c.responseSize.WithLabelValues(entry.vhost).Observe(responseSize)
+		}
+		c.sentBytes.WithLabelValues(entry.vhost).Add(responseSize * weight)
+	}
+	if c.pathTemplates != nil {
+		path := c.pathTemplates.Match(entry.path)
+		c.pathRequests.WithLabelValues(entry.vhost, path).Add(weight)
+		if hasRequestSize {
+			c.pathReceivedBytes.WithLabelValues(entry.vhost, path).Add(requestSize * weight)
+		}
+		if hasResponseSize {
+			c.pathSentBytes.WithLabelValues(entry.vhost, path).Add(responseSize * weight)
+		}
+	}
+	if c.geoIP != nil {
+		c.countryRequests.WithLabelValues(entry.vhost, c.geoIP.Country(entry.remoteAddr)).Add(weight)
+	}
+	if c.userAgentClasses != nil {
+		c.userAgentRequests.WithLabelValues(entry.vhost, c.userAgentClasses.Classify(entry.userAgent)).Add(weight)
+	}
+	if cacheStatuses[entry.cacheStatus] {
+		c.cacheResponses.WithLabelValues(entry.vhost, strings.ToLower(entry.cacheStatus)).Add(weight)
+	}
+	for _, try := range parseUpstreamTries(entry.upstreamAddr, entry.upstreamStatus, entry.upstreamResponseTime) {
+		if len(try.status) == 3 {
+			c.upstreamResponses.WithLabelValues(try.server, try.status[:1]+"xx").Add(weight)
+		}
+		if try.status == "504" {
+			c.upstreamTimeouts.WithLabelValues(try.server).Add(weight)
+		}
+		if try.retried {
+			c.upstreamRetries.WithLabelValues(try.server).Add(weight)
+		}
+		if duration, ok := parseLogNumber(try.responseTime); ok && observe {
+			c.upstreamDuration.WithLabelValues(try.server).Observe(duration)
+		}
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of nginx access log metrics
+// to the provided channel.
+func (c *NginxAccessLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.responses.Describe(ch)
+	c.requestDuration.Describe(ch)
+	c.requestSize.Describe(ch)
+	c.responseSize.Describe(ch)
+	c.receivedBytes.Describe(ch)
+	c.sentBytes.Describe(ch)
+	c.pathRequests.Describe(ch)
+	c.pathReceivedBytes.Describe(ch)
+	c.pathSentBytes.Describe(ch)
+	c.countryRequests.Describe(ch)
+	c.userAgentRequests.Describe(ch)
+	c.cacheResponses.Describe(ch)
+	c.upstreamResponses.Describe(ch)
+	c.upstreamTimeouts.Describe(ch)
+	c.upstreamRetries.Describe(ch)
+	c.upstreamDuration.Describe(ch)
+	c.unparsedLines.Describe(ch)
+	c.lines.Describe(ch)
+	c.sampledLines.Describe(ch)
+}
+
+// Collect sends the metrics of the access log lines handled so far to the provided channel.
+func (c *NginxAccessLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.responses.Collect(ch)
+	c.requestDuration.Collect(ch)
+	c.requestSize.Collect(ch)
+	c.responseSize.Collect(ch)
+	c.receivedBytes.Collect(ch)
+	c.sentBytes.Collect(ch)
+	c.pathRequests.Collect(ch)
+	c.pathReceivedBytes.Collect(ch)
+	c.pathSentBytes.Collect(ch)
+	c.countryRequests.Collect(ch)
+	c.userAgentRequests.Collect(ch)
+	c.cacheResponses.Collect(ch)
+	c.upstreamResponses.Collect(ch)
+	c.upstreamTimeouts.Collect(ch)
+	c.upstreamRetries.Collect(ch)
+	c.upstreamDuration.Collect(ch)
+	c.unparsedLines.Collect(ch)
+	c.lines.Collect(ch)
+	c.sampledLines.Collect(ch)
+}
+
+// sampled reports whether the nth line is picked by the sampling: the lines are picked evenly, a line whenever n times
+// the ratio reaches the next integer.
+func (c *NginxAccessLogCollector) sampled(n uint64) bool {
+	return c.sampleRatio >= 1 || math.Floor(float64(n)*c.sampleRatio) > math.Floor(float64(n-1)*c.sampleRatio)
+}
+
+// parseLine parses a line and picks the variables of the metrics. The vhost is $server_name, or $host if the format
+// has no $server_name. The method is $request_method, or the method of $request. The path is $uri, $request_uri or the
+// target of $request.
+func (c *NginxAccessLogCollector) parseLine(line string) (accessLogEntry, error) {
+	values, err := c.format.Parse(line)
+	if err != nil {
+		return accessLogEntry{}, err
+	}
+
+	entry := accessLogEntry{
+		vhost:                firstLogValue(values, "server_name", "host"),
+		method:               values["request_method"],
+		status:               values["status"],
+		duration:             values["request_time"],
+		requestSize:          values["request_length"],
+		responseSize:         firstLogValue(values, "bytes_sent", "body_bytes_sent"),
+		path:                 firstLogValue(values, "uri", "request_uri"),
+		remoteAddr:           values["remote_addr"],
+		userAgent:            values["http_user_agent"],
+		cacheStatus:          values["upstream_cache_status"],
+		upstreamAddr:         values["upstream_addr"],
+		upstreamStatus:       values["upstream_status"],
+		upstreamResponseTime: values["upstream_response_time"],
+	}
+	requestMethod, requestTarget, _ := strings.Cut(values["request"], " ")
+	if entry.method == "" {
+		entry.method = requestMethod
+	}
+	if entry.path == "" {
+		entry.path, _, _ = strings.Cut(requestTarget, " ")
+	}
+	if !httpMethods[entry.method] {
+		entry.method = "other"
+	}
+	if len(entry.status) != 3 {
+		entry.status = ""
+	}
+	return entry, nil
+}
+
+// parseUpstreamTries pairs the addresses of $upstream_addr with the statuses of $upstream_status and the times of
+// $upstream_response_time. The tries of an upstream are separated by commas, a colon separates the upstreams of
+// internal redirects, e.g. "10.0.0.1:80, 10.0.0.2:80 : 10.0.0.3:80". Every try but the last one of an upstream was
+// retried. The statuses and the times are left empty if their number does not match the addresses.
+func parseUpstreamTries(addrs string, statuses string, responseTimes string) []upstreamTry {
+	if addrs == "" || addrs == "-" {
+		return nil
+	}
+
+	addrGroups := strings.Split(addrs, " : ")
+	statusGroups := strings.Split(statuses, " : ")
+	timeGroups := strings.Split(responseTimes, " : ")
+	var tries []upstreamTry
+	for i, group := range addrGroups {
+		servers := strings.Split(group, ", ")
+		groupStatuses := upstreamGroupValues(statusGroups, len(addrGroups), i, len(servers))
+		groupTimes := upstreamGroupValues(timeGroups, len(addrGroups), i, len(servers))
+		for j, server := range servers {
+			try := upstreamTry{server: server, retried: j < len(servers)-1}
+			if groupStatuses != nil {
+				try.status = groupStatuses[j]
+			}
+			if groupTimes != nil {
+				try.responseTime = groupTimes[j]
+			}
+			tries = append(tries, try)
+		}
+	}
+	return tries
+}
+
+// upstreamGroupValues returns the values of the ith upstream, or nil if the number of the upstreams or of the tries
+// does not match the addresses.
+func upstreamGroupValues(groups []string, upstreams int, i int, tries int) []string {
+	if len(groups) != upstreams {
+		return nil
+	}
+	values := strings.Split(groups[i], ", ")
+	if len(values) != tries {
+		return nil
+	}
+	return values
+}
+
+// firstLogValue returns the value of the first of the variables that is in the log format.
+func firstLogValue(values logformat.Entry, variables ...string) string {
+	for _, variable := range variables {
+		if value, ok := values[variable]; ok {
+			return value
+		}
+	}
+	return ""
+}
+
+// parseLogNumber parses a number logged by nginx, a - means the value is not set.
+func parseLogNumber(value string) (float64, bool) {
+	if value == "" || value == "-" {
+		return 0, false
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, false
+	}
+	return number, true
+}
+
+// nginxAccessLog is the access log collector and the tailed log files shared by the factories. The files are nil when
+// the access log is only received over syslog.
+type nginxAccessLog struct {
+	collector *NginxAccessLogCollector
+	files     LogFiles
+}
+
+// newNginxAccessLogFactories registers the flags of the NGINX access log. The access log collector and the collector
+// of the log files share the tailer of the log or the glob of the logs of the sites.
+func newNginxAccessLogFactories() []Factory {
+	var path, format, jsonFields, syslogAddress, pathTemplates, logFilters, geoDatabase, geoCountries, agentClasses *string
+	var sampleRatio *float64
+	var accessLog shared[nginxAccessLog]
+	newAccessLog := func(env Environment) (nginxAccessLog, error) {
+		if (*path == "" && *syslogAddress == "") || env.Unit {
+			return nginxAccessLog{}, nil
+		}
+		return accessLog.get(func() (nginxAccessLog, error) {
+			logFormat, err := logformat.New(*format)
+			if err != nil {
+				return nginxAccessLog{}, fmt.Errorf("invalid access log format %v: %w", *format, err)
+			}
+			if *jsonFields != "" {
+				if *format != logformat.JSON {
+					return nginxAccessLog{}, fmt.Errorf("the JSON log fields require the json access log format, not %v", *format)
+				}
+				fields, err := logformat.LoadJSONFields(*jsonFields)
+				if err != nil {
+					return nginxAccessLog{}, err
+				}
+				logFormat = logformat.NewJSON(fields.Fields)
+			}
+			if *sampleRatio <= 0 || *sampleRatio > 1 {
+				return nginxAccessLog{}, fmt.Errorf("the access log sample ratio %v must be greater than 0 and at most 1", *sampleRatio)
+			}
+			c := NewNginxAccessLogCollector(logFormat, env.NativeHistograms, "nginx", env.ConstLabels, env.Logger)
+			c.SetSampleRatio(*sampleRatio)
+			if *pathTemplates != "" {
+				templates, err := LoadPathTemplates(*pathTemplates)
+				if err != nil {
+					return nginxAccessLog{}, err
+				}
+				c.SetPathTemplates(templates)
+			}
+			if *logFilters != "" {
+				filters, err := LoadLogFilters(*logFilters)
+				if err != nil {
+					return nginxAccessLog{}, err
+				}
+				c.SetLogFilters(filters)
+			}
+			if *geoDatabase != "" {
+				geoIP, err := OpenGeoIP(*geoDatabase, strings.Split(*geoCountries, ","))
+				if err != nil {
+					return nginxAccessLog{}, err
+				}
+				c.SetGeoIP(geoIP)
+			}
+			if *agentClasses != "" {
+				classes, err := LoadUserAgentClasses(*agentClasses)
+				if err != nil {
+					return nginxAccessLog{}, err
+				}
+				c.SetUserAgentClasses(classes)
+			}
+
+			var glob *tail.Glob
+			if *path != "" && tail.IsPattern(*path) {
+				if glob, err = tail.NewGlob(*path, time.Second, env.Logger); err != nil {
+					return nginxAccessLog{}, fmt.Errorf("invalid access log pattern %v: %w", *path, err)
+				}
+			}
+			var listener *syslog.Listener
+			if *syslogAddress != "" {
+				if listener, err = syslog.Listen(*syslogAddress, env.Logger); err != nil {
+					return nginxAccessLog{}, fmt.Errorf("failed to receive the access log over syslog: %w", err)
+				}
+				go listener.Run(env.Context, c.HandleLine)
+			}
+			accessLog := nginxAccessLog{collector: c}
+			if glob != nil {
+				go glob.Run(env.Context, func(path string, line string) {
+					c.HandleVhostLine(glob.Name(path), line)
+				})
+				accessLog.files = glob
+			} else if *path != "" {
+				tailer := tail.NewTailer(*path, time.Second, env.Logger)
+				go tailer.Run(env.Context, c.HandleLine)
+				accessLog.files = tailer
+			}
+			return accessLog, nil
+		})
+	}
+	return []Factory{
+		{
+			Name: "access_log",
+			Flags: func(app *kingpin.Application) {
+				path = app.Flag("nginx.access-log", "Path to the NGINX access log to export request duration, size and response metrics from, or a pattern of the access logs of the sites, e.g. /var/log/nginx/*.access.log. The exporter must run on the same host as NGINX.").Default("").Envar("NGINX_ACCESS_LOG").String()
+				format = app.Flag("nginx.access-log-format", "The log_format of the NGINX access log, e.g. '$remote_addr [$time_local] \"$request\" $status $request_time', or the name of a preset: "+strings.Join(logformat.Presets(), ", ")+". By default, the predefined combined format.").Default("combined").Envar("NGINX_ACCESS_LOG_FORMAT").String()
+				jsonFields = app.Flag("nginx.access-log-json-fields", "Path to the YAML file that maps the field names of the json access log format to the NGINX variables, e.g. req_time: request_time. The access log format must be json.").Default("").Envar("NGINX_ACCESS_LOG_JSON_FIELDS").String()
+				syslogAddress = app.Flag("nginx.access-log-syslog", "An address to receive the NGINX access log over syslog on, given as udp://host:port or tcp://host:port, e.g. udp://0.0.0.0:5514 for access_log syslog:server=<exporter>:5514. The lines are handled like the lines of the access log.").Default("").Envar("NGINX_ACCESS_LOG_SYSLOG").String()
+				sampleRatio = app.Flag("nginx.access-log-sample-ratio", "The ratio of the NGINX access log lines to parse, e.g. 0.1 for every tenth line, to save the CPU on busy hosts. The counters are scaled by 1/ratio, the histograms only observe the parsed lines. By default, all the lines are parsed.").Default("1").Envar("NGINX_ACCESS_LOG_SAMPLE_RATIO").Float64()
+				pathTemplates = app.Flag("nginx.access-log-path-templates", "Path to the YAML file with the templates of the request paths, e.g. /api/users/{id}. When set, the requests and bytes of the access log are also exported by the vhost and the template of the path.").Default("").Envar("NGINX_ACCESS_LOG_PATH_TEMPLATES").String()
+				logFilters = app.Flag("nginx.access-log-filters", "Path to the YAML file with the include and exclude rules of the access log lines by the regular expressions of the vhost, path and status, e.g. to drop /healthz. The histograms can be filtered separately, e.g. to observe only the 5xx responses.").Default("").Envar("NGINX_ACCESS_LOG_FILTERS").String()
+				geoDatabase = app.Flag("nginx.access-log-geoip-database", "Path to a MaxMind DB with the countries of the IP addresses, e.g. GeoLite2-Country.mmdb. When set, the requests of the access log are also exported by the vhost and the country of $remote_addr.").Default("").Envar("NGINX_ACCESS_LOG_GEOIP_DATABASE").String()
+				geoCountries = app.Flag("nginx.access-log-geoip-countries", "A comma separated list of the ISO country codes exported by the GeoIP database, e.g. DE,FR,US. The other countries are exported as other. By default, all the countries are exported.").Default("").Envar("NGINX_ACCESS_LOG_GEOIP_COUNTRIES").String()
+				agentClasses = app.Flag("nginx.access-log-user-agent-classes", "Path to the YAML file with the classes of the user agents, e.g. browser, mobile, bot and monitoring, and their patterns. When set, the requests of the access log are also exported by the vhost and the class of $http_user_agent.").Default("").Envar("NGINX_ACCESS_LOG_USER_AGENT_CLASSES").String()
+			},
+			New: func(env Environment) (prometheus.Collector, error) {
+				accessLog, err := newAccessLog(env)
+				if err != nil || accessLog.collector == nil {
+					return nil, err
+				}
+				return accessLog.collector, nil
+			},
+		},
+		{
+			Name:  "access_log_file",
+			Flags: func(app *kingpin.Application) {},
+			New: func(env Environment) (prometheus.Collector, error) {
+				accessLog, err := newAccessLog(env)
+				if err != nil || accessLog.files == nil {
+					return nil, err
+				}
+				return NewLogFileCollector(accessLog.files, "nginx", env.ConstLabels), nil
+			},
+		},
+	}
+}
@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxAccessLogCollectorParseLine(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(logformat.Combined + ` $server_name $request_time $request_length`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+
+	tests := []struct {
+		name    string
+		line    string
+		want    accessLogEntry
+		wantErr bool
+	}{
+		{
+			name: "request",
+			line: `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" example.com 0.012 80`,
+			want: accessLogEntry{vhost: "example.com", method: "GET", status: "200", duration: "0.012", requestSize: "80", responseSize: "612", path: "/", remoteAddr: "127.0.0.1", userAgent: "curl/8.1.2"},
+		},
+		{
+			name: "malformed request",
+			line: `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "\x16\x03\x01" 400 150 "-" "-" _ 0.000 7`,
+			want: accessLogEntry{vhost: "_", method: "other", status: "400", duration: "0.000", requestSize: "7", responseSize: "150", remoteAddr: "127.0.0.1", userAgent: "-"},
+		},
+		{
+			name:    "default format",
+			line:    `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2"`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := collector.parseLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseLine() error = %v, want error %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNginxAccessLogCollectorHandleLine(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(`$host "$request" $status $bytes_sent`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 612`)
+	collector.HandleLine(`example.com "POST /login HTTP/1.1" 502 -`)
+	collector.HandleLine(`garbage`)
+
+	expected := `# HELP nginx_access_log_unparsed_lines_total Total access log lines that could not be parsed
+# TYPE nginx_access_log_unparsed_lines_total counter
+nginx_access_log_unparsed_lines_total 1
+# HELP nginx_http_responses_total Total responses logged in the access log
+# TYPE nginx_http_responses_total counter
+nginx_http_responses_total{method="GET",status="200",vhost="example.com"} 1
+nginx_http_responses_total{method="POST",status="502",vhost="example.com"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_responses_total", "nginx_access_log_unparsed_lines_total"); err != nil {
+		t.Error(err)
+	}
+	if n := testutil.CollectAndCount(collector, "nginx_http_response_size_bytes"); n != 1 {
+		t.Errorf("CollectAndCount() = %v, want 1 response size histogram", n)
+	}
+}
+
+func TestParseUpstreamTries(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		addrs    string
+		statuses string
+		times    string
+		want     []upstreamTry
+	}{
+		{
+			name:     "no upstream",
+			addrs:    "-",
+			statuses: "-",
+		},
+		{
+			name:     "one try",
+			addrs:    "10.0.0.1:80",
+			statuses: "200",
+			want:     []upstreamTry{{server: "10.0.0.1:80", status: "200"}},
+		},
+		{
+			name:     "retries and an internal redirect",
+			addrs:    "10.0.0.1:80, 10.0.0.2:80 : 10.0.0.3:80",
+			statuses: "504, 502 : 200",
+			times:    "60.001, - : 0.012",
+			want: []upstreamTry{
+				{server: "10.0.0.1:80", status: "504", responseTime: "60.001", retried: true},
+				{server: "10.0.0.2:80", status: "502", responseTime: "-"},
+				{server: "10.0.0.3:80", status: "200", responseTime: "0.012"},
+			},
+		},
+		{
+			name:     "statuses do not match",
+			addrs:    "10.0.0.1:80, 10.0.0.2:80",
+			statuses: "502",
+			times:    "0.003",
+			want: []upstreamTry{
+				{server: "10.0.0.1:80", retried: true},
+				{server: "10.0.0.2:80"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseUpstreamTries(tt.addrs, tt.statuses, tt.times); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseUpstreamTries() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNginxAccessLogCollectorUpstreams(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(`$host "$request" $status "$upstream_addr" "$upstream_status"`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 "10.0.0.1:80, 10.0.0.2:80" "504, 200"`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 "10.0.0.2:80" "200"`)
+	collector.HandleLine(`example.com "GET /static HTTP/1.1" 200 "-" "-"`)
+
+	expected := `# HELP nginx_upstream_server_responses_total Total responses of the upstream servers logged in the access log by the status class
+# TYPE nginx_upstream_server_responses_total counter
+nginx_upstream_server_responses_total{code="2xx",server="10.0.0.2:80"} 2
+nginx_upstream_server_responses_total{code="5xx",server="10.0.0.1:80"} 1
+# HELP nginx_upstream_server_retries_total Total tries of the upstream servers after which the request was passed to the next server
+# TYPE nginx_upstream_server_retries_total counter
+nginx_upstream_server_retries_total{server="10.0.0.1:80"} 1
+# HELP nginx_upstream_server_timeouts_total Total tries of the upstream servers logged with the 504 status, e.g. when the server timed out
+# TYPE nginx_upstream_server_timeouts_total counter
+nginx_upstream_server_timeouts_total{server="10.0.0.1:80"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_upstream_server_responses_total", "nginx_upstream_server_retries_total", "nginx_upstream_server_timeouts_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxAccessLogCollectorCacheStatus(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(`$host "$request" $status $upstream_cache_status`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 HIT`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 HIT`)
+	collector.HandleLine(`example.com "GET /new HTTP/1.1" 200 MISS`)
+	collector.HandleLine(`example.com "POST /login HTTP/1.1" 200 -`)
+
+	expected := `# HELP nginx_http_cache_responses_total Total responses logged in the access log by the cache status
+# TYPE nginx_http_cache_responses_total counter
+nginx_http_cache_responses_total{status="hit",vhost="example.com"} 2
+nginx_http_cache_responses_total{status="miss",vhost="example.com"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_cache_responses_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxAccessLogCollectorHandleVhostLine(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(`"$request" $status`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.HandleVhostLine("example.com", `"GET / HTTP/1.1" 200`)
+	collector.HandleVhostLine("example.org", `"GET / HTTP/1.1" 200`)
+	collector.HandleVhostLine("example.org", `"GET / HTTP/1.1" 200`)
+
+	expected := `# HELP nginx_http_responses_total Total responses logged in the access log
+# TYPE nginx_http_responses_total counter
+nginx_http_responses_total{method="GET",status="200",vhost="example.com"} 1
+nginx_http_responses_total{method="GET",status="200",vhost="example.org"} 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_responses_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxAccessLogCollectorSampleRatio(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New(`$host "$request" $status $bytes_sent`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.SetSampleRatio(0.25)
+	for i := 0; i < 8; i++ {
+		collector.HandleLine(`example.com "GET / HTTP/1.1" 200 100`)
+	}
+
+	expected := `# HELP nginx_access_log_lines_total Total access log lines read, including the lines skipped by the sampling
+# TYPE nginx_access_log_lines_total counter
+nginx_access_log_lines_total 8
+# HELP nginx_access_log_sampled_lines_total Total access log lines picked by the sampling and parsed
+# TYPE nginx_access_log_sampled_lines_total counter
+nginx_access_log_sampled_lines_total 2
+# HELP nginx_http_responses_total Total responses logged in the access log
+# TYPE nginx_http_responses_total counter
+nginx_http_responses_total{method="GET",status="200",vhost="example.com"} 8
+# HELP nginx_http_sent_bytes_total Total bytes of the responses logged in the access log
+# TYPE nginx_http_sent_bytes_total counter
+nginx_http_sent_bytes_total{vhost="example.com"} 800
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"nginx_access_log_lines_total", "nginx_access_log_sampled_lines_total", "nginx_http_responses_total", "nginx_http_sent_bytes_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxAccessLogCollectorUpstreamDuration(t *testing.T) {
+	t.Parallel()
+
+	format, err := logformat.New("kube-ingress")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.HandleLine(`10.0.0.9 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 80 0.031 ` +
+		`[default-web-80] [] 10.0.0.1:80, 10.0.0.2:80 0, 612 -, 0.021 502, 200 3f2a`)
+
+	expected := `# HELP nginx_upstream_server_response_duration_seconds Duration of the tries of the upstream servers logged in the access log, from $upstream_response_time
+# TYPE nginx_upstream_server_response_duration_seconds histogram
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.005"} 0
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.01"} 0
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.025"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.05"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.1"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.25"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="0.5"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="1"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="2.5"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="5"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="10"} 1
+nginx_upstream_server_response_duration_seconds_bucket{server="10.0.0.2:80",le="+Inf"} 1
+nginx_upstream_server_response_duration_seconds_sum{server="10.0.0.2:80"} 0.021
+nginx_upstream_server_response_duration_seconds_count{server="10.0.0.2:80"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_upstream_server_response_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
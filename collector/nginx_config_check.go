@@ -0,0 +1,145 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NginxConfigCheckCollector periodically checks the nginx configuration with nginx -t and collects the result of the
+// last check. It implements prometheus.Collector interface.
+type NginxConfigCheckCollector struct {
+	binary   string
+	interval time.Duration
+	metrics  map[string]*prometheus.Desc
+	// checked is false until the first check finished
+	checked   bool
+	success   bool
+	duration  time.Duration
+	timestamp time.Time
+	mutex     sync.Mutex
+	logger    log.Logger
+}
+
+// NewNginxConfigCheckCollector creates an NginxConfigCheckCollector that runs binary -t every interval once Run is called.
+func NewNginxConfigCheckCollector(binary string, interval time.Duration, namespace string, constLabels map[string]string, logger log.Logger) *NginxConfigCheckCollector {
+	return &NginxConfigCheckCollector{
+		binary:   binary,
+		interval: interval,
+		logger:   logger,
+		metrics: map[string]*prometheus.Desc{
+			"last_check_success":           newConfigMetric(namespace, "last_check_success", "Whether the last check of the configuration with nginx -t succeeded", constLabels),
+			"last_check_duration_seconds":  newConfigMetric(namespace, "last_check_duration_seconds", "Duration of the last check of the configuration in seconds", constLabels),
+			"last_check_timestamp_seconds": newConfigMetric(namespace, "last_check_timestamp_seconds", "Time of the last check of the configuration since unix epoch in seconds", constLabels),
+		},
+	}
+}
+
+// Run checks the configuration right away and then every interval until ctx is done.
+func (c *NginxConfigCheckCollector) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.check(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// check runs nginx -t. A check that takes longer than the interval is killed and fails.
+func (c *NginxConfigCheckCollector) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, c.interval)
+	defer cancel()
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, c.binary, "-t")
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	start := time.Now()
+	err := cmd.Run()
+	duration := time.Since(start)
+	if errors.Is(ctx.Err(), context.Canceled) {
+		// the exporter is stopping
+		return
+	}
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "The nginx configuration check failed", "binary", c.binary, "error", err.Error(), "output", output.String())
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.checked = true
+	c.success = err == nil
+	c.duration = duration
+	c.timestamp = start
+}
+
+// Describe sends the super-set of all possible descriptors of the configuration check metrics
+// to the provided channel.
+func (c *NginxConfigCheckCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect sends the result of the last configuration check to the provided channel.
+func (c *NginxConfigCheckCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	if !c.checked {
+		return
+	}
+	var success float64
+	if c.success {
+		success = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["last_check_success"],
+		prometheus.GaugeValue, success)
+	ch <- prometheus.MustNewConstMetric(c.metrics["last_check_duration_seconds"],
+		prometheus.GaugeValue, c.duration.Seconds())
+	ch <- prometheus.MustNewConstMetric(c.metrics["last_check_timestamp_seconds"],
+		prometheus.GaugeValue, float64(c.timestamp.UnixNano())/1e9)
+}
+
+func newConfigMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "config", metricName), docString, nil, constLabels)
+}
+
+// newNginxConfigCheckFactory registers the flags of the configuration check, which runs on the host of NGINX.
+func newNginxConfigCheckFactory() Factory {
+	var binary *string
+	var interval *time.Duration
+	return Factory{
+		Name: "config_check",
+		Flags: func(app *kingpin.Application) {
+			binary = app.Flag("nginx.config-check-binary", "Path to the NGINX binary used to check the configuration with nginx -t every config check interval. When set, the result of the last check is exported. The exporter must run on the same host as NGINX with access to its configuration.").Default("").Envar("NGINX_CONFIG_CHECK_BINARY").String()
+			interval = app.Flag("nginx.config-check-interval", "An interval between the checks of the NGINX configuration with nginx -t. A check that takes longer is killed and fails.").Default("1m").Envar("NGINX_CONFIG_CHECK_INTERVAL").Duration()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if *binary == "" || env.Unit {
+				return nil, nil
+			}
+			if *interval <= 0 {
+				return nil, fmt.Errorf("the config check interval %v must be positive", *interval)
+			}
+			c := NewNginxConfigCheckCollector(*binary, *interval, "nginx", env.ConstLabels, env.Logger)
+			go c.Run(env.Context)
+			return c, nil
+		},
+	}
+}
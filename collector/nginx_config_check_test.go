@@ -0,0 +1,60 @@
+package collector
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxConfigCheckCollector(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		script      string
+		wantSuccess string
+	}{
+		{
+			name:        "valid configuration",
+			script:      "#!/bin/sh\necho 'nginx: configuration file /etc/nginx/nginx.conf test is successful'\n",
+			wantSuccess: "1",
+		},
+		{
+			name:        "invalid configuration",
+			script:      "#!/bin/sh\necho 'nginx: [emerg] unknown directive \"servr\"' >&2\nexit 1\n",
+			wantSuccess: "0",
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			binary := filepath.Join(t.TempDir(), "nginx")
+			if err := os.WriteFile(binary, []byte(test.script), 0o700); err != nil {
+				t.Fatalf("WriteFile() error = %v", err)
+			}
+			collector := NewNginxConfigCheckCollector(binary, time.Minute, "nginx", nil, log.NewNopLogger())
+			if n := testutil.CollectAndCount(collector); n != 0 {
+				t.Errorf("CollectAndCount() before the first check = %v, want 0", n)
+			}
+
+			collector.check(context.Background())
+			expected := "# HELP nginx_config_last_check_success Whether the last check of the configuration with nginx -t succeeded\n" +
+				"# TYPE nginx_config_last_check_success gauge\n" +
+				"nginx_config_last_check_success " + test.wantSuccess + "\n"
+			if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_config_last_check_success"); err != nil {
+				t.Error(err)
+			}
+			if n := testutil.CollectAndCount(collector); n != 3 {
+				t.Errorf("CollectAndCount() = %v, want 3", n)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// errorLogLine matches a line of the nginx error log, e.g.
+// 2026/10/14 17:53:38 [error] 1234#1234: *5 upstream timed out (110: Connection timed out) while reading response header.
+var errorLogLine = regexp.MustCompile(`^\d{4}/\d{2}/\d{2} \d{2}:\d{2}:\d{2} \[([a-z]+)\] (.*)$`)
+
+// errorLogSeverities are the levels of the error_log directive.
+var errorLogSeverities = []string{"debug", "info", "notice", "warn", "error", "crit", "alert", "emerg"}
+
+// errorLogClasses are the classes of the well-known messages by a text of the message, a message is counted in the
+// first class it matches.
+var errorLogClasses = []struct {
+	class string
+	text  string
+}{
+	{"upstream_timed_out", "upstream timed out"},
+	{"no_live_upstreams", "no live upstreams"},
+	{"upstream_connection_refused", "(111: Connection refused) while connecting to upstream"},
+	{"upstream_prematurely_closed", "upstream prematurely closed connection"},
+	{"too_many_open_files", "Too many open files"},
+	{"worker_connections_not_enough", "worker_connections are not enough"},
+	{"ssl_handshake_failed", "SSL_do_handshake() failed"},
+	{"client_body_too_large", "client intended to send too large body"},
+	{"limiting_requests", "limiting requests"},
+	{"limiting_connections", "limiting connections"},
+}
+
+// NginxErrorLogCollector collects the messages of the nginx error log by severity and by well-known class.
+// It implements prometheus.Collector interface.
+type NginxErrorLogCollector struct {
+	messages      *prometheus.CounterVec
+	knownMessages *prometheus.CounterVec
+	unparsedLines prometheus.Counter
+	logger        log.Logger
+}
+
+// NewNginxErrorLogCollector creates an NginxErrorLogCollector. All the severities and classes start at zero.
+func NewNginxErrorLogCollector(namespace string, constLabels map[string]string, logger log.Logger) *NginxErrorLogCollector {
+	c := &NginxErrorLogCollector{
+		logger: logger,
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "error_log_messages_total",
+			Help:        "Total messages logged in the error log",
+			ConstLabels: constLabels,
+		}, []string{"severity"}),
+		knownMessages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "error_log_known_messages_total",
+			Help:        "Total well-known messages logged in the error log",
+			ConstLabels: constLabels,
+		}, []string{"class"}),
+		unparsedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "error_log_unparsed_lines_total",
+			Help:        "Total error log lines that could not be parsed",
+			ConstLabels: constLabels,
+		}),
+	}
+	for _, severity := range errorLogSeverities {
+		c.messages.WithLabelValues(severity)
+	}
+	for _, class := range errorLogClasses {
+		c.knownMessages.WithLabelValues(class.class)
+	}
+	return c
+}
+
+// HandleLine updates the metrics with a line of the error log.
+func (c *NginxErrorLogCollector) HandleLine(line string) {
+	severity, class, ok := parseErrorLogLine(line)
+	if !ok {
+		c.unparsedLines.Inc()
+		level.Debug(c.logger).Log("msg", "Error parsing error log line", "line", line)
+		return
+	}
+	c.messages.WithLabelValues(severity).Inc()
+	if class != "" {
+		c.knownMessages.WithLabelValues(class).Inc()
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of nginx error log metrics
+// to the provided channel.
+func (c *NginxErrorLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.messages.Describe(ch)
+	c.knownMessages.Describe(ch)
+	c.unparsedLines.Describe(ch)
+}
+
+// Collect sends the metrics of the error log lines handled so far to the provided channel.
+func (c *NginxErrorLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.messages.Collect(ch)
+	c.knownMessages.Collect(ch)
+	c.unparsedLines.Collect(ch)
+}
+
+// parseErrorLogLine returns the severity of a line and the class of its message, empty if the message is not a
+// well-known one.
+func parseErrorLogLine(line string) (string, string, bool) {
+	matches := errorLogLine.FindStringSubmatch(line)
+	if matches == nil {
+		return "", "", false
+	}
+	severity, message := matches[1], matches[2]
+	known := false
+	for _, s := range errorLogSeverities {
+		if s == severity {
+			known = true
+			break
+		}
+	}
+	if !known {
+		return "", "", false
+	}
+	for _, class := range errorLogClasses {
+		if strings.Contains(message, class.text) {
+			return severity, class.class, true
+		}
+	}
+	return severity, "", true
+}
+
+// nginxErrorLog is the error log collector and the tailer of the log shared by the factories.
+type nginxErrorLog struct {
+	collector *NginxErrorLogCollector
+	tailer    *tail.Tailer
+}
+
+// newNginxErrorLogFactories registers the flag of the NGINX error log. The error log collector and the collector of the
+// log file share the tailer of the log.
+func newNginxErrorLogFactories() []Factory {
+	var path *string
+	var errorLog shared[nginxErrorLog]
+	newErrorLog := func(env Environment) nginxErrorLog {
+		if *path == "" || env.Unit {
+			return nginxErrorLog{}
+		}
+		errorLog, _ := errorLog.get(func() (nginxErrorLog, error) {
+			c := NewNginxErrorLogCollector("nginx", env.ConstLabels, env.Logger)
+			tailer := tail.NewTailer(*path, time.Second, env.Logger)
+			go tailer.Run(env.Context, c.HandleLine)
+			return nginxErrorLog{collector: c, tailer: tailer}, nil
+		})
+		return errorLog
+	}
+	return []Factory{
+		{
+			Name: "error_log",
+			Flags: func(app *kingpin.Application) {
+				path = app.Flag("nginx.error-log", "Path to the NGINX error log to export the messages by severity and by well-known class from. The exporter must run on the same host as NGINX.").Default("").Envar("NGINX_ERROR_LOG").String()
+			},
+			New: func(env Environment) (prometheus.Collector, error) {
+				if errorLog := newErrorLog(env); errorLog.collector != nil {
+					return errorLog.collector, nil
+				}
+				return nil, nil
+			},
+		},
+		{
+			Name:  "error_log_file",
+			Flags: func(app *kingpin.Application) {},
+			New: func(env Environment) (prometheus.Collector, error) {
+				if errorLog := newErrorLog(env); errorLog.tailer != nil {
+					return NewLogFileCollector(errorLog.tailer, "nginx", env.ConstLabels), nil
+				}
+				return nil, nil
+			},
+		},
+	}
+}
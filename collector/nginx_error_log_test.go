@@ -0,0 +1,59 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestParseErrorLogLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		line         string
+		wantSeverity string
+		wantClass    string
+		wantOk       bool
+	}{
+		{
+			name:         "upstream timed out",
+			line:         `2026/10/14 17:53:38 [error] 1234#1234: *5 upstream timed out (110: Connection timed out) while reading response header from upstream, client: 10.0.0.1, server: example.com, request: "GET / HTTP/1.1", upstream: "http://10.0.0.2:80/", host: "example.com"`,
+			wantSeverity: "error",
+			wantClass:    "upstream_timed_out",
+			wantOk:       true,
+		},
+		{
+			name:         "too many open files",
+			line:         `2026/10/14 17:53:38 [crit] 1234#1234: accept4() failed (24: Too many open files)`,
+			wantSeverity: "crit",
+			wantClass:    "too_many_open_files",
+			wantOk:       true,
+		},
+		{
+			name:         "unclassified message",
+			line:         `2026/10/14 17:53:38 [notice] 1#1: signal process started`,
+			wantSeverity: "notice",
+			wantOk:       true,
+		},
+		{
+			name:   "unknown severity",
+			line:   `2026/10/14 17:53:38 [fatal] 1#1: signal process started`,
+			wantOk: false,
+		},
+		{
+			name:   "continuation line",
+			line:   `    while reading upstream`,
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			severity, class, ok := parseErrorLogLine(tt.line)
+			if ok != tt.wantOk {
+				t.Fatalf("parseErrorLogLine() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if severity != tt.wantSeverity || class != tt.wantClass {
+				t.Errorf("parseErrorLogLine() = %v, %v, want %v, %v", severity, class, tt.wantSeverity, tt.wantClass)
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package collector
 
 import (
+	"context"
 	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/alecthomas/kingpin/v2"
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	plusapi "github.com/nginxinc/nginx-prometheus-exporter/client/plus"
 	"github.com/prometheus/client_golang/prometheus"
 )
 
@@ -29,6 +37,17 @@ type LabelUpdater interface {
 // NginxPlusCollector collects NGINX Plus metrics. It implements prometheus.Collector interface.
 type NginxPlusCollector struct {
 	nginxClient                    *plusclient.NginxClient
+	apiClient                      *plusapi.NginxClient
+	endpoints                      map[string]bool
+	cacheResponses                 map[string]cacheResponses
+	connectionsLimit               uint64
+	maxPeers                       int
+	upstreamOverflows              map[string]*overflowSum[plusclient.Peer]
+	streamUpstreamOverflows        map[string]*overflowSum[plusclient.StreamPeer]
+	serverZoneOverflow             *overflowSum[plusclient.ServerZone]
+	locationZoneOverflow           *overflowSum[plusclient.LocationZone]
+	cacheOverflow                  *overflowSum[plusclient.HTTPCache]
+	timeout                        time.Duration
 	totalMetrics                   map[string]*prometheus.Desc
 	serverZoneMetrics              map[string]*prometheus.Desc
 	upstreamMetrics                map[string]*prometheus.Desc
@@ -42,7 +61,14 @@ type NginxPlusCollector struct {
 	limitRequestMetrics            map[string]*prometheus.Desc
 	limitConnectionMetrics         map[string]*prometheus.Desc
 	streamLimitConnectionMetrics   map[string]*prometheus.Desc
+	cacheZoneMetrics               map[string]*prometheus.Desc
+	slabMetrics                    map[string]*prometheus.Desc
+	slabSlotMetrics                map[string]*prometheus.Desc
+	workerMetrics                  map[string]*prometheus.Desc
+	licenseMetrics                 map[string]*prometheus.Desc
 	upMetric                       prometheus.Gauge
+	scrapeErrors                   *prometheus.CounterVec
+	lastError                      *prometheus.Desc
 	mutex                          sync.Mutex
 	variableLabelNames             VariableLabelNames
 	upstreamServerLabels           map[string][]string
@@ -223,10 +249,69 @@ func NewVariableLabelNames(upstreamServerVariableLabelNames []string, serverZone
 	}
 }
 
+// SetAPIClient sets the client of the API endpoints and fields that are not supported by the nginx-plus-go-client.
+// The metrics of the SSL failure reasons and the license are only exported when the client is set. When it is set, the
+// upstreams are also fetched by the client, which decodes them while they are read.
+func (c *NginxPlusCollector) SetAPIClient(apiClient *plusapi.NginxClient) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.apiClient = apiClient
+}
+
+// SetTimeout sets the deadline of the requests of the API client of a scrape. The requests are not bounded by the
+// collector if timeout is 0.
+func (c *NginxPlusCollector) SetTimeout(timeout time.Duration) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.timeout = timeout
+}
+
+// SetConnectionsLimit sets the maximum number of client connections, i.e. the worker_connections multiplied by the number
+// of the worker processes. The limit is not exported if it is 0.
+func (c *NginxPlusCollector) SetConnectionsLimit(limit uint64) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.connectionsLimit = limit
+}
+
+// SetMaxPeers limits the number of the exported servers of every upstream and the number of the exported server, location
+// and cache zones. The servers and the zones above the limit are summed in the series of the _overflow server or zone.
+// The exported servers and zones are kept while they exist. They are not limited if max is 0.
+func (c *NginxPlusCollector) SetMaxPeers(max int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.maxPeers = max
+	if max <= 0 {
+		c.upstreamOverflows, c.streamUpstreamOverflows = nil, nil
+		c.serverZoneOverflow, c.locationZoneOverflow, c.cacheOverflow = nil, nil, nil
+		return
+	}
+	c.upstreamOverflows = make(map[string]*overflowSum[plusclient.Peer])
+	c.streamUpstreamOverflows = make(map[string]*overflowSum[plusclient.StreamPeer])
+	c.serverZoneOverflow = newOverflowSum(max, overflowServerZone, serverZoneCounters, serverZoneRequests)
+	c.locationZoneOverflow = newOverflowSum(max, overflowLocationZone, locationZoneCounters, locationZoneRequests)
+	c.cacheOverflow = newOverflowSum(max, overflowCache, cacheCounters, cacheResponsesTotal)
+}
+
+// isOverflow reports whether the server or the zone is the sum of the ones above the limit of SetMaxPeers.
+func (c *NginxPlusCollector) isOverflow(name string) bool {
+	return c.maxPeers > 0 && name == overflowName
+}
+
+// EnableCacheHitRatio enables the hit ratio of the cache zones. The ratio is computed between the consecutive scrapes
+// of the collector, so it is only meaningful if the exporter is scraped by a single Prometheus server.
+func (c *NginxPlusCollector) EnableCacheHitRatio() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.cacheResponses = make(map[string]cacheResponses)
+}
+
 // NewNginxPlusCollector creates an NginxPlusCollector.
 func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string, variableLabelNames VariableLabelNames, constLabels map[string]string, logger log.Logger) *NginxPlusCollector {
 	upstreamServerVariableLabelNames := append(variableLabelNames.UpstreamServerVariableLabelNames, variableLabelNames.UpstreamServerPeerVariableLabelNames...)
+	upstreamServerStateLabelNames := append(append([]string{}, upstreamServerVariableLabelNames...), "state")
 	streamUpstreamServerVariableLabelNames := append(variableLabelNames.StreamUpstreamServerVariableLabelNames, variableLabelNames.StreamUpstreamServerPeerVariableLabelNames...)
+	streamUpstreamServerStateLabelNames := append(append([]string{}, streamUpstreamServerVariableLabelNames...), "state")
 	return &NginxPlusCollector{
 		variableLabelNames:             variableLabelNames,
 		upstreamServerLabels:           make(map[string][]string),
@@ -238,15 +323,33 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 		nginxClient:                    nginxClient,
 		logger:                         logger,
 		totalMetrics: map[string]*prometheus.Desc{
-			"connections_accepted":  newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
-			"connections_dropped":   newGlobalMetric(namespace, "connections_dropped", "Dropped client connections", constLabels),
-			"connections_active":    newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
-			"connections_idle":      newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
-			"http_requests_total":   newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
-			"http_requests_current": newGlobalMetric(namespace, "http_requests_current", "Current http requests", constLabels),
-			"ssl_handshakes":        newGlobalMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", constLabels),
-			"ssl_handshakes_failed": newGlobalMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", constLabels),
-			"ssl_session_reuses":    newGlobalMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", constLabels),
+			"connections_accepted":                  newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
+			"connections_dropped":                   newGlobalMetric(namespace, "connections_dropped", "Dropped client connections", constLabels),
+			"connections_active":                    newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
+			"connections_idle":                      newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
+			"connections_limit":                     newGlobalMetric(namespace, "connections_limit", "Maximum number of client connections set in the configuration", constLabels),
+			"http_requests_total":                   newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+			"http_requests_current":                 newGlobalMetric(namespace, "http_requests_current", "Current http requests", constLabels),
+			"ssl_handshakes":                        newGlobalMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", constLabels),
+			"ssl_handshakes_failed":                 newGlobalMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", constLabels),
+			"ssl_session_reuses":                    newGlobalMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", constLabels),
+			"processes_respawned":                   newGlobalMetric(namespace, "processes_respawned", "Total number of abnormally terminated and respawned child processes", constLabels),
+			"nginx_info":                            prometheus.NewDesc(prometheus.BuildFQName(namespace, "nginx", "info"), "NGINX Plus build information", []string{"version", "build", "address"}, constLabels),
+			"nginx_generation":                      newGlobalMetric(namespace, "nginx_generation", "Total number of configuration reloads", constLabels),
+			"nginx_load_timestamp":                  newGlobalMetric(namespace, "nginx_load_timestamp_seconds", "Unix time of the last reload of the configuration", constLabels),
+			"nginx_timestamp":                       newGlobalMetric(namespace, "nginx_timestamp_seconds", "Current Unix time reported by NGINX Plus", constLabels),
+			"ssl_no_common_protocol":                newGlobalMetric(namespace, "ssl_no_common_protocol", "SSL handshakes failed because of no common protocol", constLabels),
+			"ssl_no_common_cipher":                  newGlobalMetric(namespace, "ssl_no_common_cipher", "SSL handshakes failed because of no shared cipher", constLabels),
+			"ssl_handshake_timeout":                 newGlobalMetric(namespace, "ssl_handshake_timeout", "SSL handshakes failed because of a timeout", constLabels),
+			"ssl_peer_rejected_cert":                newGlobalMetric(namespace, "ssl_peer_rejected_cert", "Failed SSL handshakes when nginx presented the certificate to the client but it was rejected with a corresponding alert message", constLabels),
+			"ssl_verify_failures_no_cert":           newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"reason": "no_cert"})),
+			"ssl_verify_failures_expired_cert":      newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"reason": "expired_cert"})),
+			"ssl_verify_failures_revoked_cert":      newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"reason": "revoked_cert"})),
+			"ssl_verify_failures_hostname_mismatch": newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"reason": "hostname_mismatch"})),
+			"server_zones_overflow":                 newGlobalMetric(namespace, "server_zones_overflow", "Server zones that are not exported because of the limit of the exported zones", constLabels),
+			"location_zones_overflow":               newGlobalMetric(namespace, "location_zones_overflow", "Location zones that are not exported because of the limit of the exported zones", constLabels),
+			"cache_zones_overflow":                  newGlobalMetric(namespace, "cache_zones_overflow", "Cache zones that are not exported because of the limit of the exported zones", constLabels),
+			"ssl_verify_failures_other":             newGlobalMetric(namespace, "ssl_verify_failures", "SSL certificate verification errors", MergeLabels(constLabels, prometheus.Labels{"reason": "other"})),
 		},
 		serverZoneMetrics: map[string]*prometheus.Desc{
 			"processing":            newServerZoneMetric(namespace, "processing", "Client requests that are currently being processed", variableLabelNames.ServerZoneVariableLabelNames, constLabels),
@@ -317,14 +420,20 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"ssl_session_reuses":    newStreamServerZoneMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", variableLabelNames.StreamServerZoneVariableLabelNames, constLabels),
 		},
 		upstreamMetrics: map[string]*prometheus.Desc{
-			"keepalives": newUpstreamMetric(namespace, "keepalives", "Idle keepalive connections", constLabels),
-			"zombies":    newUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client requests", constLabels),
+			"keepalives":      newUpstreamMetric(namespace, "keepalives", "Idle keepalive connections", constLabels),
+			"zombies":         newUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client requests", constLabels),
+			"queue_size":      newUpstreamMetric(namespace, "queue_size", "The current number of requests in the queue", constLabels),
+			"queue_max_size":  newUpstreamMetric(namespace, "queue_max_size", "The maximum number of requests that can be in the queue at the same time", constLabels),
+			"queue_overflows": newUpstreamMetric(namespace, "queue_overflows", "The total number of requests rejected due to the queue overflow", constLabels),
+			"peers_overflow":  newUpstreamMetric(namespace, "peers_overflow", "Servers of the group that are not exported because of the limit of the exported servers", constLabels),
 		},
 		streamUpstreamMetrics: map[string]*prometheus.Desc{
-			"zombies": newStreamUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client connections", constLabels),
+			"zombies":        newStreamUpstreamMetric(namespace, "zombies", "Servers removed from the group but still processing active client connections", constLabels),
+			"peers_overflow": newStreamUpstreamMetric(namespace, "peers_overflow", "Servers of the group that are not exported because of the limit of the exported servers", constLabels),
 		},
 		upstreamServerMetrics: map[string]*prometheus.Desc{
 			"state":                   newUpstreamServerMetric(namespace, "state", "Current state", upstreamServerVariableLabelNames, constLabels),
+			"state_info":              newUpstreamServerMetric(namespace, "state_info", "Current state as a label, the value is always 1", upstreamServerStateLabelNames, constLabels),
 			"active":                  newUpstreamServerMetric(namespace, "active", "Active connections", upstreamServerVariableLabelNames, constLabels),
 			"limit":                   newUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", upstreamServerVariableLabelNames, constLabels),
 			"requests":                newUpstreamServerMetric(namespace, "requests", "Total client requests", upstreamServerVariableLabelNames, constLabels),
@@ -342,6 +451,7 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"health_checks_checks":    newUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", upstreamServerVariableLabelNames, constLabels),
 			"health_checks_fails":     newUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", upstreamServerVariableLabelNames, constLabels),
 			"health_checks_unhealthy": newUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", upstreamServerVariableLabelNames, constLabels),
+			"last_passed":             newUpstreamServerMetric(namespace, "health_checks_last_passed", "Whether the last health check was successful and passed tests", upstreamServerVariableLabelNames, constLabels),
 			"codes_100":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "100"})),
 			"codes_101":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "101"})),
 			"codes_102":               newUpstreamServerMetric(namespace, "responses_codes", "Total responses sent to clients", upstreamServerVariableLabelNames, MergeLabels(constLabels, prometheus.Labels{"code": "102"})),
@@ -388,6 +498,7 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 		},
 		streamUpstreamServerMetrics: map[string]*prometheus.Desc{
 			"state":                   newStreamUpstreamServerMetric(namespace, "state", "Current state", streamUpstreamServerVariableLabelNames, constLabels),
+			"state_info":              newStreamUpstreamServerMetric(namespace, "state_info", "Current state as a label, the value is always 1", streamUpstreamServerStateLabelNames, constLabels),
 			"active":                  newStreamUpstreamServerMetric(namespace, "active", "Active connections", streamUpstreamServerVariableLabelNames, constLabels),
 			"limit":                   newStreamUpstreamServerMetric(namespace, "limit", "Limit for connections which corresponds to the max_conns parameter of the upstream server. Zero value means there is no limit", streamUpstreamServerVariableLabelNames, constLabels),
 			"sent":                    newStreamUpstreamServerMetric(namespace, "sent", "Bytes sent to this server", streamUpstreamServerVariableLabelNames, constLabels),
@@ -401,6 +512,7 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"health_checks_checks":    newStreamUpstreamServerMetric(namespace, "health_checks_checks", "Total health check requests", streamUpstreamServerVariableLabelNames, constLabels),
 			"health_checks_fails":     newStreamUpstreamServerMetric(namespace, "health_checks_fails", "Failed health checks", streamUpstreamServerVariableLabelNames, constLabels),
 			"health_checks_unhealthy": newStreamUpstreamServerMetric(namespace, "health_checks_unhealthy", "How many times the server became unhealthy (state 'unhealthy')", streamUpstreamServerVariableLabelNames, constLabels),
+			"last_passed":             newStreamUpstreamServerMetric(namespace, "health_checks_last_passed", "Whether the last health check was successful and passed tests", streamUpstreamServerVariableLabelNames, constLabels),
 			"ssl_handshakes":          newStreamUpstreamServerMetric(namespace, "ssl_handshakes", "Successful SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
 			"ssl_handshakes_failed":   newStreamUpstreamServerMetric(namespace, "ssl_handshakes_failed", "Failed SSL handshakes", streamUpstreamServerVariableLabelNames, constLabels),
 			"ssl_session_reuses":      newStreamUpstreamServerMetric(namespace, "ssl_session_reuses", "Session reuses during SSL handshake", streamUpstreamServerVariableLabelNames, constLabels),
@@ -495,7 +607,58 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 			"rejected":         newStreamLimitConnectionMetric(namespace, "rejected", "Total number of connections that were rejected", constLabels),
 			"rejected_dry_run": newStreamLimitConnectionMetric(namespace, "rejected_dry_run", "Total number of connections accounted as rejected in the dry run mode", constLabels),
 		},
-		upMetric: newUpMetric(namespace, constLabels),
+		cacheZoneMetrics: map[string]*prometheus.Desc{
+			"size":                      newCacheZoneMetric(namespace, "size", "Current size of the cache", constLabels),
+			"hit_ratio":                 newCacheZoneMetric(namespace, "hit_ratio", "Ratio of the responses read from the cache to all the responses since the previous scrape", constLabels),
+			"max_size":                  newCacheZoneMetric(namespace, "max_size", "Limit on the maximum size of the cache specified in the configuration", constLabels),
+			"cold":                      newCacheZoneMetric(namespace, "cold", "Whether the cache loader process is still loading data from disk into the cache", constLabels),
+			"hit_responses":             newCacheZoneMetric(namespace, "hit_responses", "Total number of valid responses read from the cache", constLabels),
+			"hit_bytes":                 newCacheZoneMetric(namespace, "hit_bytes", "Total number of bytes of valid responses read from the cache", constLabels),
+			"stale_responses":           newCacheZoneMetric(namespace, "stale_responses", "Total number of expired responses read from the cache (see proxy_cache_use_stale and other *_cache_use_stale directives)", constLabels),
+			"stale_bytes":               newCacheZoneMetric(namespace, "stale_bytes", "Total number of bytes of expired responses read from the cache", constLabels),
+			"updating_responses":        newCacheZoneMetric(namespace, "updating_responses", "Total number of expired responses read from the cache while responses were being updated", constLabels),
+			"updating_bytes":            newCacheZoneMetric(namespace, "updating_bytes", "Total number of bytes of expired responses read from the cache while responses were being updated", constLabels),
+			"revalidated_responses":     newCacheZoneMetric(namespace, "revalidated_responses", "Total number of expired and revalidated responses read from the cache (see proxy_cache_revalidate and other *_cache_revalidate directives)", constLabels),
+			"revalidated_bytes":         newCacheZoneMetric(namespace, "revalidated_bytes", "Total number of bytes of expired and revalidated responses read from the cache", constLabels),
+			"miss_responses":            newCacheZoneMetric(namespace, "miss_responses", "Total number of responses not found in the cache", constLabels),
+			"miss_bytes":                newCacheZoneMetric(namespace, "miss_bytes", "Total number of bytes of responses not found in the cache", constLabels),
+			"expired_responses":         newCacheZoneMetric(namespace, "expired_responses", "Total number of expired responses not taken from the cache", constLabels),
+			"expired_bytes":             newCacheZoneMetric(namespace, "expired_bytes", "Total number of bytes of expired responses not taken from the cache", constLabels),
+			"expired_responses_written": newCacheZoneMetric(namespace, "expired_responses_written", "Total number of expired responses written to the cache", constLabels),
+			"expired_bytes_written":     newCacheZoneMetric(namespace, "expired_bytes_written", "Total number of bytes of expired responses written to the cache", constLabels),
+			"bypass_responses":          newCacheZoneMetric(namespace, "bypass_responses", "Total number of responses not looked up in the cache due to the proxy_cache_bypass and other *_cache_bypass directives", constLabels),
+			"bypass_bytes":              newCacheZoneMetric(namespace, "bypass_bytes", "Total number of bytes of responses not looked up in the cache", constLabels),
+			"bypass_responses_written":  newCacheZoneMetric(namespace, "bypass_responses_written", "Total number of responses not looked up in the cache that were written to the cache", constLabels),
+			"bypass_bytes_written":      newCacheZoneMetric(namespace, "bypass_bytes_written", "Total number of bytes of responses not looked up in the cache that were written to the cache", constLabels),
+		},
+		slabMetrics: map[string]*prometheus.Desc{
+			"pages_used": newSlabMetric(namespace, "pages_used", "Current number of used memory pages", constLabels),
+			"pages_free": newSlabMetric(namespace, "pages_free", "Current number of free memory pages", constLabels),
+		},
+		slabSlotMetrics: map[string]*prometheus.Desc{
+			"used":  newSlabSlotMetric(namespace, "used", "Current number of used memory slots", constLabels),
+			"free":  newSlabSlotMetric(namespace, "free", "Current number of free memory slots", constLabels),
+			"reqs":  newSlabSlotMetric(namespace, "reqs", "Total number of attempts to allocate memory of specified size", constLabels),
+			"fails": newSlabSlotMetric(namespace, "fails", "Number of unsuccessful attempts to allocate memory of specified size", constLabels),
+		},
+		workerMetrics: map[string]*prometheus.Desc{
+			"connection_accepted":   newWorkerMetric(namespace, "connection_accepted", "The total number of accepted client connections", constLabels),
+			"connection_dropped":    newWorkerMetric(namespace, "connection_dropped", "The total number of dropped client connections", constLabels),
+			"connection_active":     newWorkerMetric(namespace, "connection_active", "The current number of active client connections", constLabels),
+			"connection_idle":       newWorkerMetric(namespace, "connection_idle", "The current number of idle client connections", constLabels),
+			"http_requests_total":   newWorkerMetric(namespace, "http_requests_total", "The total number of client requests received by the worker process", constLabels),
+			"http_requests_current": newWorkerMetric(namespace, "http_requests_current", "The current number of client requests that are currently being processed by the worker process", constLabels),
+		},
+		licenseMetrics: map[string]*prometheus.Desc{
+			"active_till":       newGlobalMetric(namespace, "license_expiration_timestamp_seconds", "Unix time when the license expires", constLabels),
+			"eval":              newGlobalMetric(namespace, "license_eval", "Whether the license is an evaluation license", constLabels),
+			"reporting_healthy": newGlobalMetric(namespace, "license_reporting_healthy", "Whether the usage reporting is healthy", constLabels),
+			"reporting_fails":   newGlobalMetric(namespace, "license_reporting_fails", "Number of failed usage reports", constLabels),
+			"reporting_grace":   newGlobalMetric(namespace, "license_reporting_grace_period_seconds", "Remaining grace period of the usage reporting in seconds", constLabels),
+		},
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("plus", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
 	}
 }
 
@@ -503,6 +666,8 @@ func NewNginxPlusCollector(nginxClient *plusclient.NginxClient, namespace string
 // to the provided channel.
 func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
 
 	for _, m := range c.totalMetrics {
 		ch <- m
@@ -543,6 +708,21 @@ func (c *NginxPlusCollector) Describe(ch chan<- *prometheus.Desc) {
 	for _, m := range c.streamLimitConnectionMetrics {
 		ch <- m
 	}
+	for _, m := range c.cacheZoneMetrics {
+		ch <- m
+	}
+	for _, m := range c.slabMetrics {
+		ch <- m
+	}
+	for _, m := range c.slabSlotMetrics {
+		ch <- m
+	}
+	for _, m := range c.workerMetrics {
+		ch <- m
+	}
+	for _, m := range c.licenseMetrics {
+		ch <- m
+	}
 }
 
 // Collect fetches metrics from NGINX Plus and sends them to the provided channel.
@@ -550,42 +730,82 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 	c.mutex.Lock() // To protect metrics from concurrent collects
 	defer c.mutex.Unlock()
 
-	stats, err := c.nginxClient.GetStats()
+	stats, err := c.getStats()
 	if err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
 		level.Warn(c.logger).Log("msg", "Error getting stats", "error", err.Error())
 		return
 	}
 
 	c.upMetric.Set(nginxUp)
 	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
+
+	if c.collects("connections") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
+			prometheus.CounterValue, float64(stats.Connections.Accepted))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_dropped"],
+			prometheus.CounterValue, float64(stats.Connections.Dropped))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
+			prometheus.GaugeValue, float64(stats.Connections.Active))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_idle"],
+			prometheus.GaugeValue, float64(stats.Connections.Idle))
+		if c.connectionsLimit > 0 {
+			ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_limit"],
+				prometheus.GaugeValue, float64(c.connectionsLimit))
+		}
+	}
+	if c.collects("http_requests") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_total"],
+			prometheus.CounterValue, float64(stats.HTTPRequests.Total))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_current"],
+			prometheus.GaugeValue, float64(stats.HTTPRequests.Current))
+	}
+	if c.collects("ssl") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes"],
+			prometheus.CounterValue, float64(stats.SSL.Handshakes))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes_failed"],
+			prometheus.CounterValue, float64(stats.SSL.HandshakesFailed))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_session_reuses"],
+			prometheus.CounterValue, float64(stats.SSL.SessionReuses))
+	}
+
+	if stats.SSLFailures != nil {
+		c.collectSSLFailures(ch, stats.SSLFailures)
+	}
+	if stats.License != nil {
+		c.collectLicense(ch, stats.License)
+	}
 
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_accepted"],
-		prometheus.CounterValue, float64(stats.Connections.Accepted))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_dropped"],
-		prometheus.CounterValue, float64(stats.Connections.Dropped))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_active"],
-		prometheus.GaugeValue, float64(stats.Connections.Active))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["connections_idle"],
-		prometheus.GaugeValue, float64(stats.Connections.Idle))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_total"],
-		prometheus.CounterValue, float64(stats.HTTPRequests.Total))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["http_requests_current"],
-		prometheus.GaugeValue, float64(stats.HTTPRequests.Current))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes"],
-		prometheus.CounterValue, float64(stats.SSL.Handshakes))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_handshakes_failed"],
-		prometheus.CounterValue, float64(stats.SSL.HandshakesFailed))
-	ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_session_reuses"],
-		prometheus.CounterValue, float64(stats.SSL.SessionReuses))
-
-	for name, zone := range stats.ServerZones {
+	if c.collects("processes") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["processes_respawned"],
+			prometheus.CounterValue, float64(stats.Processes.Respawned))
+	}
+	if c.collects("nginx") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["nginx_info"],
+			prometheus.GaugeValue, 1, stats.NginxInfo.Version, stats.NginxInfo.Build, stats.NginxInfo.Address)
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["nginx_generation"],
+			prometheus.GaugeValue, float64(stats.NginxInfo.Generation))
+		c.collectTimestamp(ch, "nginx_load_timestamp", stats.NginxInfo.LoadTimestamp)
+		c.collectTimestamp(ch, "nginx_timestamp", stats.NginxInfo.Timestamp)
+	}
+
+	serverZones, overflowServerZones := c.serverZoneOverflow.limit(stats.ServerZones)
+	for name, zone := range serverZones {
 		labelValues := []string{name}
 		varLabelValues := c.getServerZoneLabelValues(name)
 
 		if c.variableLabelNames.ServerZoneVariableLabelNames != nil && len(varLabelValues) != len(c.variableLabelNames.ServerZoneVariableLabelNames) {
-			level.Warn(c.logger).Log("msg", "wrong number of labels for http zone, empty labels will be used instead", "zone", name, "expected", len(c.variableLabelNames.ServerZoneVariableLabelNames), "got", len(varLabelValues))
+			// the overflow zone has no labels of its own
+			if !c.isOverflow(name) {
+				level.Warn(c.logger).Log("msg", "wrong number of labels for http zone, empty labels will be used instead", "zone", name, "expected", len(c.variableLabelNames.ServerZoneVariableLabelNames), "got", len(varLabelValues))
+			}
 			for range c.variableLabelNames.ServerZoneVariableLabelNames {
 				labelValues = append(labelValues, "")
 			}
@@ -700,6 +920,10 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.serverZoneMetrics["ssl_session_reuses"],
 			prometheus.CounterValue, float64(zone.SSL.SessionReuses), labelValues...)
 	}
+	if c.maxPeers > 0 && c.collects("server_zones") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["server_zones_overflow"],
+			prometheus.GaugeValue, float64(overflowServerZones))
+	}
 
 	for name, zone := range stats.StreamServerZones {
 		labelValues := []string{name}
@@ -738,12 +962,15 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 	}
 
 	for name, upstream := range stats.Upstreams {
-		for _, peer := range upstream.Peers {
+		peers, overflow := c.limitPeers(name, upstream.Peers)
+		for _, peer := range peers {
 			labelValues := []string{name, peer.Server}
 			varLabelValues := c.getUpstreamServerLabelValues(name)
 
 			if c.variableLabelNames.UpstreamServerVariableLabelNames != nil && len(varLabelValues) != len(c.variableLabelNames.UpstreamServerVariableLabelNames) {
-				level.Warn(c.logger).Log("msg", "wrong number of labels for upstream, empty labels will be used instead", "upstream", name, "expected", len(c.variableLabelNames.UpstreamServerVariableLabelNames), "got", len(varLabelValues))
+				if !c.isOverflow(peer.Server) {
+					level.Warn(c.logger).Log("msg", "wrong number of labels for upstream, empty labels will be used instead", "upstream", name, "expected", len(c.variableLabelNames.UpstreamServerVariableLabelNames), "got", len(varLabelValues))
+				}
 				for range c.variableLabelNames.UpstreamServerVariableLabelNames {
 					labelValues = append(labelValues, "")
 				}
@@ -754,7 +981,9 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			upstreamServer := fmt.Sprintf("%v/%v", name, peer.Server)
 			varPeerLabelValues := c.getUpstreamServerPeerLabelValues(upstreamServer)
 			if c.variableLabelNames.UpstreamServerPeerVariableLabelNames != nil && len(varPeerLabelValues) != len(c.variableLabelNames.UpstreamServerPeerVariableLabelNames) {
-				level.Warn(c.logger).Log("msg", "wrong number of labels for upstream peer, empty labels will be used instead", "upstream", name, "peer", peer.Server, "expected", len(c.variableLabelNames.UpstreamServerPeerVariableLabelNames), "got", len(varPeerLabelValues))
+				if !c.isOverflow(peer.Server) {
+					level.Warn(c.logger).Log("msg", "wrong number of labels for upstream peer, empty labels will be used instead", "upstream", name, "peer", peer.Server, "expected", len(c.variableLabelNames.UpstreamServerPeerVariableLabelNames), "got", len(varPeerLabelValues))
+				}
 				for range c.variableLabelNames.UpstreamServerPeerVariableLabelNames {
 					labelValues = append(labelValues, "")
 				}
@@ -762,8 +991,17 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 				labelValues = append(labelValues, varPeerLabelValues...)
 			}
 
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state"],
-				prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
+			// the state and the times of the servers cannot be summed in the overflow server
+			if !c.isOverflow(peer.Server) {
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state"],
+					prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["state_info"],
+					prometheus.GaugeValue, 1, append(labelValues[:len(labelValues):len(labelValues)], peer.State)...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["header_time"],
+					prometheus.GaugeValue, float64(peer.HeaderTime), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["response_time"],
+					prometheus.GaugeValue, float64(peer.ResponseTime), labelValues...)
+			}
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["active"],
 				prometheus.GaugeValue, float64(peer.Active), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["limit"],
@@ -788,10 +1026,6 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 				prometheus.CounterValue, float64(peer.Fails), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["unavail"],
 				prometheus.CounterValue, float64(peer.Unavail), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["header_time"],
-				prometheus.GaugeValue, float64(peer.HeaderTime), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["response_time"],
-				prometheus.GaugeValue, float64(peer.ResponseTime), labelValues...)
 
 			if peer.HealthChecks != (plusclient.HealthChecks{}) {
 				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["health_checks_checks"],
@@ -800,6 +1034,14 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 					prometheus.CounterValue, float64(peer.HealthChecks.Fails), labelValues...)
 				ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["health_checks_unhealthy"],
 					prometheus.CounterValue, float64(peer.HealthChecks.Unhealthy), labelValues...)
+				if peer.HealthChecks.Checks > 0 && !c.isOverflow(peer.Server) {
+					var lastPassed float64
+					if peer.HealthChecks.LastPassed {
+						lastPassed = 1.0
+					}
+					ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["last_passed"],
+						prometheus.GaugeValue, lastPassed, labelValues...)
+				}
 			}
 			ch <- prometheus.MustNewConstMetric(c.upstreamServerMetrics["codes_100"],
 				prometheus.CounterValue, float64(peer.Responses.Codes.HTTPContinue), labelValues...)
@@ -892,15 +1134,36 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.GaugeValue, float64(upstream.Keepalives), name)
 		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["zombies"],
 			prometheus.GaugeValue, float64(upstream.Zombies), name)
+		if c.maxPeers > 0 {
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["peers_overflow"],
+				prometheus.GaugeValue, float64(overflow), name)
+		}
+		// the queue is only reported for the upstreams with the queue directive, which requires a positive max size
+		if upstream.Queue.MaxSize > 0 {
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_size"],
+				prometheus.GaugeValue, float64(upstream.Queue.Size), name)
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_max_size"],
+				prometheus.GaugeValue, float64(upstream.Queue.MaxSize), name)
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["queue_overflows"],
+				prometheus.CounterValue, float64(upstream.Queue.Overflows), name)
+		}
+	}
+	for name := range c.upstreamOverflows {
+		if _, ok := stats.Upstreams[name]; !ok {
+			delete(c.upstreamOverflows, name)
+		}
 	}
 
 	for name, upstream := range stats.StreamUpstreams {
-		for _, peer := range upstream.Peers {
+		peers, overflow := c.limitStreamPeers(name, upstream.Peers)
+		for _, peer := range peers {
 			labelValues := []string{name, peer.Server}
 			varLabelValues := c.getStreamUpstreamServerLabelValues(name)
 
 			if c.variableLabelNames.StreamUpstreamServerVariableLabelNames != nil && len(varLabelValues) != len(c.variableLabelNames.StreamUpstreamServerVariableLabelNames) {
-				level.Warn(c.logger).Log("msg", "wrong number of labels for stream server, empty labels will be used instead", "server", name, "labels", c.variableLabelNames.StreamUpstreamServerVariableLabelNames, "values", varLabelValues)
+				if !c.isOverflow(peer.Server) {
+					level.Warn(c.logger).Log("msg", "wrong number of labels for stream server, empty labels will be used instead", "server", name, "labels", c.variableLabelNames.StreamUpstreamServerVariableLabelNames, "values", varLabelValues)
+				}
 				for range c.variableLabelNames.StreamUpstreamServerVariableLabelNames {
 					labelValues = append(labelValues, "")
 				}
@@ -911,7 +1174,9 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			upstreamServer := fmt.Sprintf("%v/%v", name, peer.Server)
 			varPeerLabelValues := c.getStreamUpstreamServerPeerLabelValues(upstreamServer)
 			if c.variableLabelNames.StreamUpstreamServerPeerVariableLabelNames != nil && len(varPeerLabelValues) != len(c.variableLabelNames.StreamUpstreamServerPeerVariableLabelNames) {
-				level.Warn(c.logger).Log("msg", "wrong number of labels for stream upstream peer, empty labels will be used instead", "server", upstreamServer, "labels", c.variableLabelNames.StreamUpstreamServerPeerVariableLabelNames, "values", varPeerLabelValues)
+				if !c.isOverflow(peer.Server) {
+					level.Warn(c.logger).Log("msg", "wrong number of labels for stream upstream peer, empty labels will be used instead", "server", upstreamServer, "labels", c.variableLabelNames.StreamUpstreamServerPeerVariableLabelNames, "values", varPeerLabelValues)
+				}
 				for range c.variableLabelNames.StreamUpstreamServerPeerVariableLabelNames {
 					labelValues = append(labelValues, "")
 				}
@@ -919,20 +1184,25 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 				labelValues = append(labelValues, varPeerLabelValues...)
 			}
 
-			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["state"],
-				prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
+			// the state and the times of the servers cannot be summed in the overflow server
+			if !c.isOverflow(peer.Server) {
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["state"],
+					prometheus.GaugeValue, upstreamServerStates[peer.State], labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["state_info"],
+					prometheus.GaugeValue, 1, append(labelValues[:len(labelValues):len(labelValues)], peer.State)...)
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["connect_time"],
+					prometheus.GaugeValue, float64(peer.ConnectTime), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["first_byte_time"],
+					prometheus.GaugeValue, float64(peer.FirstByteTime), labelValues...)
+				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["response_time"],
+					prometheus.GaugeValue, float64(peer.ResponseTime), labelValues...)
+			}
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["active"],
 				prometheus.GaugeValue, float64(peer.Active), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["limit"],
 				prometheus.GaugeValue, float64(peer.MaxConns), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["connections"],
 				prometheus.CounterValue, float64(peer.Connections), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["connect_time"],
-				prometheus.GaugeValue, float64(peer.ConnectTime), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["first_byte_time"],
-				prometheus.GaugeValue, float64(peer.FirstByteTime), labelValues...)
-			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["response_time"],
-				prometheus.GaugeValue, float64(peer.ResponseTime), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["sent"],
 				prometheus.CounterValue, float64(peer.Sent), labelValues...)
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["received"],
@@ -948,6 +1218,14 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 					prometheus.CounterValue, float64(peer.HealthChecks.Fails), labelValues...)
 				ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["health_checks_unhealthy"],
 					prometheus.CounterValue, float64(peer.HealthChecks.Unhealthy), labelValues...)
+				if peer.HealthChecks.Checks > 0 && !c.isOverflow(peer.Server) {
+					var lastPassed float64
+					if peer.HealthChecks.LastPassed {
+						lastPassed = 1.0
+					}
+					ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["last_passed"],
+						prometheus.GaugeValue, lastPassed, labelValues...)
+				}
 			}
 			ch <- prometheus.MustNewConstMetric(c.streamUpstreamServerMetrics["ssl_handshakes"],
 				prometheus.CounterValue, float64(peer.SSL.Handshakes), labelValues...)
@@ -958,6 +1236,15 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		}
 		ch <- prometheus.MustNewConstMetric(c.streamUpstreamMetrics["zombies"],
 			prometheus.GaugeValue, float64(upstream.Zombies), name)
+		if c.maxPeers > 0 {
+			ch <- prometheus.MustNewConstMetric(c.streamUpstreamMetrics["peers_overflow"],
+				prometheus.GaugeValue, float64(overflow), name)
+		}
+	}
+	for name := range c.streamUpstreamOverflows {
+		if _, ok := stats.StreamUpstreams[name]; !ok {
+			delete(c.streamUpstreamOverflows, name)
+		}
 	}
 
 	if stats.StreamZoneSync != nil {
@@ -980,7 +1267,8 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 			prometheus.GaugeValue, float64(stats.StreamZoneSync.Status.NodesOnline))
 	}
 
-	for name, zone := range stats.LocationZones {
+	locationZones, overflowLocationZones := c.locationZoneOverflow.limit(stats.LocationZones)
+	for name, zone := range locationZones {
 		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["requests"],
 			prometheus.CounterValue, float64(zone.Requests), name)
 		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["responses_1xx"],
@@ -1080,6 +1368,10 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.locationZoneMetrics["codes_507"],
 			prometheus.CounterValue, float64(zone.Responses.Codes.HTTPInsufficientStorage), name)
 	}
+	if c.maxPeers > 0 && c.collects("location_zones") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["location_zones_overflow"],
+			prometheus.GaugeValue, float64(overflowLocationZones))
+	}
 
 	for name, zone := range stats.Resolvers {
 		ch <- prometheus.MustNewConstMetric(c.resolverMetrics["name"],
@@ -1125,6 +1417,194 @@ func (c *NginxPlusCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.streamLimitConnectionMetrics["rejected"], prometheus.CounterValue, float64(zone.Rejected), name)
 		ch <- prometheus.MustNewConstMetric(c.streamLimitConnectionMetrics["rejected_dry_run"], prometheus.CounterValue, float64(zone.RejectedDryRun), name)
 	}
+
+	caches, overflowCaches := c.cacheOverflow.limit(stats.Caches)
+	for name, zone := range caches {
+		var cold float64
+		if zone.Cold {
+			cold = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["size"], prometheus.GaugeValue, float64(zone.Size), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["max_size"], prometheus.GaugeValue, float64(zone.MaxSize), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["cold"], prometheus.GaugeValue, cold, name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["hit_responses"], prometheus.CounterValue, float64(zone.Hit.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["hit_bytes"], prometheus.CounterValue, float64(zone.Hit.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["stale_responses"], prometheus.CounterValue, float64(zone.Stale.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["stale_bytes"], prometheus.CounterValue, float64(zone.Stale.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["updating_responses"], prometheus.CounterValue, float64(zone.Updating.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["updating_bytes"], prometheus.CounterValue, float64(zone.Updating.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["revalidated_responses"], prometheus.CounterValue, float64(zone.Revalidated.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["revalidated_bytes"], prometheus.CounterValue, float64(zone.Revalidated.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["miss_responses"], prometheus.CounterValue, float64(zone.Miss.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["miss_bytes"], prometheus.CounterValue, float64(zone.Miss.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["expired_responses"], prometheus.CounterValue, float64(zone.Expired.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["expired_bytes"], prometheus.CounterValue, float64(zone.Expired.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["expired_responses_written"], prometheus.CounterValue, float64(zone.Expired.ResponsesWritten), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["expired_bytes_written"], prometheus.CounterValue, float64(zone.Expired.BytesWritten), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["bypass_responses"], prometheus.CounterValue, float64(zone.Bypass.Responses), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["bypass_bytes"], prometheus.CounterValue, float64(zone.Bypass.Bytes), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["bypass_responses_written"], prometheus.CounterValue, float64(zone.Bypass.ResponsesWritten), name)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["bypass_bytes_written"], prometheus.CounterValue, float64(zone.Bypass.BytesWritten), name)
+	}
+	if c.maxPeers > 0 && c.collects("caches") {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["cache_zones_overflow"],
+			prometheus.GaugeValue, float64(overflowCaches))
+	}
+	if c.cacheResponses != nil {
+		c.collectCacheHitRatio(ch, caches)
+	}
+
+	for name, zone := range stats.Slabs {
+		ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_used"], prometheus.GaugeValue, float64(zone.Pages.Used), name)
+		ch <- prometheus.MustNewConstMetric(c.slabMetrics["pages_free"], prometheus.GaugeValue, float64(zone.Pages.Free), name)
+
+		for size, slot := range zone.Slots {
+			ch <- prometheus.MustNewConstMetric(c.slabSlotMetrics["used"], prometheus.GaugeValue, float64(slot.Used), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabSlotMetrics["free"], prometheus.GaugeValue, float64(slot.Free), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabSlotMetrics["reqs"], prometheus.CounterValue, float64(slot.Reqs), name, size)
+			ch <- prometheus.MustNewConstMetric(c.slabSlotMetrics["fails"], prometheus.CounterValue, float64(slot.Fails), name, size)
+		}
+	}
+
+	for _, worker := range stats.Workers {
+		workerID := strconv.Itoa(worker.ID)
+		workerPID := strconv.FormatUint(worker.ProcessID, 10)
+
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_accepted"], prometheus.CounterValue, float64(worker.Connections.Accepted), workerID, workerPID)
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_dropped"], prometheus.CounterValue, float64(worker.Connections.Dropped), workerID, workerPID)
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_active"], prometheus.GaugeValue, float64(worker.Connections.Active), workerID, workerPID)
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["connection_idle"], prometheus.GaugeValue, float64(worker.Connections.Idle), workerID, workerPID)
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_total"], prometheus.CounterValue, float64(worker.HTTP.HTTPRequests.Total), workerID, workerPID)
+		ch <- prometheus.MustNewConstMetric(c.workerMetrics["http_requests_current"], prometheus.GaugeValue, float64(worker.HTTP.HTTPRequests.Current), workerID, workerPID)
+	}
+}
+
+// collectSSLFailures sends the metrics of the SSL failure reasons that the API reports.
+func (c *NginxPlusCollector) collectSSLFailures(ch chan<- prometheus.Metric, ssl *plusapi.SSL) {
+	counters := map[string]*uint64{
+		"ssl_no_common_protocol": ssl.NoCommonProtocol,
+		"ssl_no_common_cipher":   ssl.NoCommonCipher,
+		"ssl_handshake_timeout":  ssl.HandshakeTimeout,
+		"ssl_peer_rejected_cert": ssl.PeerRejectedCert,
+	}
+	for name, value := range counters {
+		if value != nil {
+			ch <- prometheus.MustNewConstMetric(c.totalMetrics[name], prometheus.CounterValue, float64(*value))
+		}
+	}
+	if ssl.VerifyFailures != nil {
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_verify_failures_no_cert"],
+			prometheus.CounterValue, float64(ssl.VerifyFailures.NoCert))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_verify_failures_expired_cert"],
+			prometheus.CounterValue, float64(ssl.VerifyFailures.ExpiredCert))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_verify_failures_revoked_cert"],
+			prometheus.CounterValue, float64(ssl.VerifyFailures.RevokedCert))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_verify_failures_hostname_mismatch"],
+			prometheus.CounterValue, float64(ssl.VerifyFailures.HostnameMismatch))
+		ch <- prometheus.MustNewConstMetric(c.totalMetrics["ssl_verify_failures_other"],
+			prometheus.CounterValue, float64(ssl.VerifyFailures.Other))
+	}
+}
+
+// collectLicense sends the license metrics that the API reports.
+func (c *NginxPlusCollector) collectLicense(ch chan<- prometheus.Metric, license *plusapi.License) {
+	var eval, healthy float64
+	if license.Eval {
+		eval = 1.0
+	}
+	if license.Reporting.Healthy {
+		healthy = 1.0
+	}
+	ch <- prometheus.MustNewConstMetric(c.licenseMetrics["active_till"], prometheus.GaugeValue, float64(license.ActiveTill))
+	ch <- prometheus.MustNewConstMetric(c.licenseMetrics["eval"], prometheus.GaugeValue, eval)
+	ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_healthy"], prometheus.GaugeValue, healthy)
+	ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_fails"], prometheus.GaugeValue, float64(license.Reporting.Fails))
+	ch <- prometheus.MustNewConstMetric(c.licenseMetrics["reporting_grace"], prometheus.GaugeValue, float64(license.Reporting.Grace))
+}
+
+// collectTimestamp sends the timestamp reported by the API, e.g. 2023-10-01T12:00:00.000Z, as Unix time.
+func (c *NginxPlusCollector) collectTimestamp(ch chan<- prometheus.Metric, metric string, timestamp string) {
+	t, err := time.Parse(time.RFC3339, timestamp)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Error parsing timestamp", "timestamp", timestamp, "error", err.Error())
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.totalMetrics[metric],
+		prometheus.GaugeValue, float64(t.UnixNano())/float64(time.Second))
+}
+
+// cacheResponses are the responses of a cache zone read from the cache and all its responses.
+type cacheResponses struct {
+	hits  uint64
+	total uint64
+}
+
+// collectCacheHitRatio sends the hit ratio of the cache zones since the previous scrape. The hits are the responses
+// read from the cache (hit, stale, updating and revalidated). The ratio is not sent on the first scrape of a zone and
+// when the zone had no responses since the previous scrape.
+func (c *NginxPlusCollector) collectCacheHitRatio(ch chan<- prometheus.Metric, caches plusclient.Caches) {
+	current := make(map[string]cacheResponses, len(caches))
+	for name, zone := range caches {
+		responses := cacheResponses{
+			hits: zone.Hit.Responses + zone.Stale.Responses + zone.Updating.Responses + zone.Revalidated.Responses,
+		}
+		responses.total = responses.hits + zone.Miss.Responses + zone.Expired.Responses + zone.Bypass.Responses
+		current[name] = responses
+
+		previous, ok := c.cacheResponses[name]
+		if !ok {
+			continue
+		}
+		// the counters are reset when the zone is recreated, e.g. on a configuration reload
+		if responses.total < previous.total || responses.hits < previous.hits {
+			previous = cacheResponses{}
+		}
+		if responses.total == previous.total {
+			continue
+		}
+		ratio := float64(responses.hits-previous.hits) / float64(responses.total-previous.total)
+		ch <- prometheus.MustNewConstMetric(c.cacheZoneMetrics["hit_ratio"], prometheus.GaugeValue, ratio, name)
+	}
+	c.cacheResponses = current
+}
+
+// limitPeers limits the peers of the upstream to the maximum of SetMaxPeers, the peers above it are summed in the peer
+// with the _overflow server. It returns the peers and the number of the peers above the maximum.
+func (c *NginxPlusCollector) limitPeers(upstream string, peers []plusclient.Peer) ([]plusclient.Peer, int) {
+	if c.upstreamOverflows == nil {
+		return peers, 0
+	}
+	overflow, ok := c.upstreamOverflows[upstream]
+	if !ok {
+		overflow = newOverflowSum(c.maxPeers, overflowPeer, peerCounters, peerRequests)
+		c.upstreamOverflows[upstream] = overflow
+	}
+	limited, overflows := overflow.limit(peersByServer(peers, func(peer plusclient.Peer) string { return peer.Server }, overflowPeer))
+	peers = make([]plusclient.Peer, 0, len(limited))
+	for server, peer := range limited {
+		peer.Server = server
+		peers = append(peers, peer)
+	}
+	return peers, overflows
+}
+
+// limitStreamPeers limits the peers of the stream upstream like limitPeers.
+func (c *NginxPlusCollector) limitStreamPeers(upstream string, peers []plusclient.StreamPeer) ([]plusclient.StreamPeer, int) {
+	if c.streamUpstreamOverflows == nil {
+		return peers, 0
+	}
+	overflow, ok := c.streamUpstreamOverflows[upstream]
+	if !ok {
+		overflow = newOverflowSum(c.maxPeers, overflowStreamPeer, streamPeerCounters, streamPeerConnections)
+		c.streamUpstreamOverflows[upstream] = overflow
+	}
+	limited, overflows := overflow.limit(peersByServer(peers, func(peer plusclient.StreamPeer) string { return peer.Server }, overflowStreamPeer))
+	peers = make([]plusclient.StreamPeer, 0, len(limited))
+	for server, peer := range limited {
+		peer.Server = server
+		peers = append(peers, peer)
+	}
+	return peers, overflows
 }
 
 var upstreamServerStates = map[string]float64{
@@ -1195,3 +1675,130 @@ func newLimitConnectionMetric(namespace string, metricName string, docString str
 func newStreamLimitConnectionMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
 	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "stream_limit_connection", metricName), docString, []string{"zone"}, constLabels)
 }
+
+func newCacheZoneMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", metricName), docString, []string{"zone"}, constLabels)
+}
+
+func newSlabMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "slab", metricName), docString, []string{"zone"}, constLabels)
+}
+
+func newSlabSlotMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "slab_slot", metricName), docString, []string{"zone", "slot"}, constLabels)
+}
+
+func newWorkerMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "worker", metricName), docString, []string{"worker_id", "pid"}, constLabels)
+}
+
+// newPlusClient creates an NGINX Plus client for the highest API version supported by both NGINX Plus and the client.
+func newPlusClient(ctx context.Context, httpClient *http.Client, scrapeURI string) (*plusclient.NginxClient, error) {
+	versions, err := plusapi.GetAPIVersions(ctx, httpClient, scrapeURI)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the API versions: %w", err)
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+	for _, v := range versions {
+		// the client fails only for the versions it does not support
+		if plusClient, err := plusclient.NewNginxClient(scrapeURI, plusclient.WithHTTPClient(httpClient), plusclient.WithAPIVersion(v)); err == nil {
+			return plusClient, nil
+		}
+	}
+	return nil, fmt.Errorf("none of the API versions %v is supported by the client", versions)
+}
+
+// nginxPlusClients are the clients of the NGINX Plus API shared by the factories.
+type nginxPlusClients struct {
+	client    *plusclient.NginxClient
+	apiClient *plusapi.NginxClient
+}
+
+// newNginxPlusFactories registers the flags of the NGINX Plus API, which is the scrape URI for NGINX Plus or the NGINX
+// Plus scrape URI next to the stub_status page of NGINX. The NGINX Plus and the keyval collectors share the clients.
+func newNginxPlusFactories() []Factory {
+	var scrapeURI, collect, keyvalInfo *string
+	var maxPeers *uint
+	var hitRatio, keyvals *bool
+	var clients shared[nginxPlusClients]
+	newClients := func(env Environment) (nginxPlusClients, error) {
+		if *scrapeURI != "" && (env.Plus || env.Unit) {
+			return nginxPlusClients{}, fmt.Errorf("the NGINX Plus scrape URI %v is only supported with the stub_status scrape URI of NGINX", *scrapeURI)
+		}
+		uri := *scrapeURI
+		if env.Plus {
+			uri = env.ScrapeURI
+		}
+		if uri == "" {
+			return nginxPlusClients{}, nil
+		}
+		return clients.get(func() (nginxPlusClients, error) {
+			httpClient, apiURI, err := env.NewHTTPClient(uri, "")
+			if err != nil {
+				return nginxPlusClients{}, fmt.Errorf("failed to parse the NGINX Plus API address %v: %w", uri, err)
+			}
+			plusClient, err := env.Retry(func() (interface{}, error) {
+				return newPlusClient(env.Context, httpClient, apiURI)
+			})
+			if err != nil {
+				return nginxPlusClients{}, fmt.Errorf("failed to create the NGINX Plus client: %w", err)
+			}
+			client := plusClient.(*plusclient.NginxClient)
+			level.Info(env.Logger).Log("msg", "Using NGINX Plus API version", "version", client.Version())
+			return nginxPlusClients{client: client, apiClient: plusapi.NewNginxClient(httpClient, apiURI, client.Version())}, nil
+		})
+	}
+	return []Factory{
+		{
+			Name: "plus",
+			Flags: func(app *kingpin.Application) {
+				scrapeURI = app.Flag("nginx.plus-scrape-uri", "A URI or unix domain socket path of the NGINX Plus API to scrape in addition to the stub_status page given with the scrape URI, e.g. when the fleet has both NGINX and NGINX Plus. The NGINX Plus metrics are exported with the nginxplus namespace next to the NGINX metrics.").Default("").Envar("NGINX_PLUS_SCRAPE_URI").String()
+				maxPeers = app.Flag("nginx.plus-max-peers", "The maximum number of the exported servers of every NGINX Plus upstream and of the exported server, location and cache zones. The servers and the zones above the limit are summed in the _overflow server or zone. By default, all the servers and zones are exported.").Default("0").Envar("NGINX_PLUS_MAX_PEERS").Uint()
+				hitRatio = app.Flag("nginx.plus-cache-hit-ratio", "Export the hit ratio of the NGINX Plus cache zones computed between the consecutive scrapes of the exporter.").Default("false").Envar("NGINX_PLUS_CACHE_HIT_RATIO").Bool()
+				collect = app.Flag("nginx.plus-collect", "A comma separated list of the NGINX Plus API endpoints fetched on every scrape, e.g. connections,http_requests,server_zones. By default, all the endpoints are fetched. The supported endpoints are: "+strings.Join(NginxPlusEndpoints(), ", ")+".").Default("").Envar("NGINX_PLUS_COLLECT").String()
+			},
+			New: func(env Environment) (prometheus.Collector, error) {
+				clients, err := newClients(env)
+				if err != nil || clients.client == nil {
+					return nil, err
+				}
+				c := NewNginxPlusCollector(clients.client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), env.ConstLabels, env.Logger)
+				c.SetAPIClient(clients.apiClient)
+				if *collect != "" {
+					if err := c.SetEndpoints(strings.Split(*collect, ",")); err != nil {
+						return nil, err
+					}
+				}
+				c.SetTimeout(env.Timeout)
+				c.SetConnectionsLimit(env.ConnectionsLimit)
+				c.SetMaxPeers(int(*maxPeers))
+				if *hitRatio {
+					c.EnableCacheHitRatio()
+				}
+				return c, nil
+			},
+		},
+		{
+			Name: "plus_keyval",
+			Flags: func(app *kingpin.Application) {
+				keyvalInfo = app.Flag("nginx.plus-keyval-info", "A comma separated list of the NGINX Plus keyval zones whose key-value pairs are exported as info metrics. The values become labels, so only the zones with a few keys should be set. Implies -nginx.plus-keyvals.").Default("").Envar("NGINX_PLUS_KEYVAL_INFO").String()
+				keyvals = app.Flag("nginx.plus-keyvals", "Export the number of keys and their size of the NGINX Plus keyval zones. All the key-value pairs of the zones are fetched on every scrape.").Default("false").Envar("NGINX_PLUS_KEYVALS").Bool()
+			},
+			New: func(env Environment) (prometheus.Collector, error) {
+				if !*keyvals && *keyvalInfo == "" {
+					return nil, nil
+				}
+				clients, err := newClients(env)
+				if err != nil || clients.client == nil {
+					return nil, err
+				}
+				c := NewNginxPlusKeyvalCollector(clients.client, "nginxplus", env.ConstLabels, env.Logger)
+				if *keyvalInfo != "" {
+					c.SetInfoZones(strings.Split(*keyvalInfo, ","))
+				}
+				return c, nil
+			},
+		},
+	}
+}
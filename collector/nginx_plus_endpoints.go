@@ -0,0 +1,262 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	plusapi "github.com/nginxinc/nginx-prometheus-exporter/client/plus"
+)
+
+// nginxPlusEndpoints fetch the stats of the NGINX Plus API endpoints that can be selected for the NginxPlusCollector.
+var nginxPlusEndpoints = map[string]func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error{
+	"nginx": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		info, err := c.nginxClient.GetNginxInfo()
+		if err == nil {
+			stats.NginxInfo = *info
+		}
+		return err
+	},
+	"processes": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		processes, err := c.nginxClient.GetProcesses()
+		if err == nil {
+			stats.Processes = *processes
+		}
+		return err
+	},
+	"connections": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		connections, err := c.nginxClient.GetConnections()
+		if err == nil {
+			stats.Connections = *connections
+		}
+		return err
+	},
+	"slabs": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		slabs, err := c.nginxClient.GetSlabs()
+		if err == nil {
+			stats.Slabs = *slabs
+		}
+		return err
+	},
+	"http_requests": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		requests, err := c.nginxClient.GetHTTPRequests()
+		if err == nil {
+			stats.HTTPRequests = *requests
+		}
+		return err
+	},
+	"ssl": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		ssl, err := c.nginxClient.GetSSL()
+		if err == nil {
+			stats.SSL = *ssl
+		}
+		return err
+	},
+	"server_zones": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		zones, err := c.nginxClient.GetServerZones()
+		if err == nil {
+			stats.ServerZones = *zones
+		}
+		return err
+	},
+	"location_zones": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		zones, err := c.nginxClient.GetLocationZones()
+		if err == nil {
+			stats.LocationZones = *zones
+		}
+		return err
+	},
+	// the upstreams with many peers are decoded while they are read by the API client if it is set
+	"upstreams": func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		var upstreams *plusclient.Upstreams
+		var err error
+		if c.apiClient != nil {
+			upstreams, err = c.apiClient.GetUpstreams(ctx)
+		} else {
+			upstreams, err = c.nginxClient.GetUpstreams()
+		}
+		if err == nil {
+			stats.Upstreams = *upstreams
+		}
+		return err
+	},
+	"caches": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		caches, err := c.nginxClient.GetCaches()
+		if err == nil {
+			stats.Caches = *caches
+		}
+		return err
+	},
+	"limit_reqs": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		limitReqs, err := c.nginxClient.GetHTTPLimitReqs()
+		if err == nil {
+			stats.HTTPLimitRequests = *limitReqs
+		}
+		return err
+	},
+	"limit_conns": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		limitConns, err := c.nginxClient.GetHTTPConnectionsLimit()
+		if err == nil {
+			stats.HTTPLimitConnections = *limitConns
+		}
+		return err
+	},
+	"resolvers": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		resolvers, err := c.nginxClient.GetResolvers()
+		if err == nil {
+			stats.Resolvers = *resolvers
+		}
+		return err
+	},
+	"stream_server_zones": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		zones, err := c.nginxClient.GetStreamServerZones()
+		if err == nil {
+			stats.StreamServerZones = *zones
+		}
+		return err
+	},
+	"stream_upstreams": func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		var upstreams *plusclient.StreamUpstreams
+		var err error
+		if c.apiClient != nil {
+			upstreams, err = c.apiClient.GetStreamUpstreams(ctx)
+		} else {
+			upstreams, err = c.nginxClient.GetStreamUpstreams()
+		}
+		if err == nil {
+			stats.StreamUpstreams = *upstreams
+		}
+		return err
+	},
+	"stream_zone_sync": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		zoneSync, err := c.nginxClient.GetStreamZoneSync()
+		stats.StreamZoneSync = zoneSync
+		return err
+	},
+	"stream_limit_conns": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		limitConns, err := c.nginxClient.GetStreamConnectionsLimit()
+		if err == nil {
+			stats.StreamLimitConnections = *limitConns
+		}
+		return err
+	},
+	"workers": func(_ context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		workers, err := c.nginxClient.GetWorkers()
+		stats.Workers = workers
+		return err
+	},
+	// the license is fetched by the API client, see SetAPIClient
+	"license": func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+		if c.apiClient == nil {
+			return nil
+		}
+		license, err := c.apiClient.GetLicense(ctx)
+		if err != nil {
+			// the license metrics are left out, the other metrics are still exported
+			level.Warn(c.logger).Log("msg", "Error getting license", "error", err.Error())
+			return nil
+		}
+		stats.License = license
+		return nil
+	},
+}
+
+// NginxPlusEndpoints returns the sorted names of the NGINX Plus API endpoints that can be selected with SetEndpoints.
+func NginxPlusEndpoints() []string {
+	names := make([]string, 0, len(nginxPlusEndpoints))
+	for name := range nginxPlusEndpoints {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetEndpoints limits the NGINX Plus API endpoints fetched on every scrape to the given ones.
+// All the endpoints are fetched if no endpoints are given.
+func (c *NginxPlusCollector) SetEndpoints(endpoints []string) error {
+	var selected map[string]bool
+	for _, name := range endpoints {
+		if _, ok := nginxPlusEndpoints[name]; !ok {
+			return fmt.Errorf("unknown NGINX Plus API endpoint %q, the supported endpoints are %v", name, NginxPlusEndpoints())
+		}
+		if selected == nil {
+			selected = make(map[string]bool)
+		}
+		selected[name] = true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.endpoints = selected
+	return nil
+}
+
+// collects reports whether the endpoint is fetched on every scrape.
+func (c *NginxPlusCollector) collects(endpoint string) bool {
+	return c.endpoints == nil || c.endpoints[endpoint]
+}
+
+// nginxPlusStats are the stats of the nginx-plus-go-client and the stats fetched by the API client, see SetAPIClient.
+type nginxPlusStats struct {
+	plusclient.Stats
+	// SSLFailures and License are nil if the API client is not set, the endpoint is not selected or it failed.
+	SSLFailures *plusapi.SSL
+	License     *plusapi.License
+}
+
+// getSSLFailures fetches the SSL failure reasons by the API client next to the ssl endpoint of the nginx-plus-go-client.
+func getSSLFailures(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error {
+	ssl, err := c.apiClient.GetSSL(ctx)
+	if err != nil {
+		// the SSL failure metrics are left out, the other metrics are still exported
+		level.Warn(c.logger).Log("msg", "Error getting SSL failure reasons", "error", err.Error())
+		return nil
+	}
+	stats.SSLFailures = ssl
+	return nil
+}
+
+// getStats fetches the stats of the selected endpoints concurrently, so a scrape takes about as long as the slowest
+// endpoint. The requests of the API client share a context with the deadline of the timeout, see SetTimeout. The
+// nginx-plus-go-client does not take a context, so its requests are only bounded by the timeout of its HTTP client,
+// which is the same timeout for the clients created by the exporter.
+func (c *NginxPlusCollector) getStats() (*nginxPlusStats, error) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if c.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+	}
+	defer cancel()
+
+	var fetches []func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error
+	for _, name := range NginxPlusEndpoints() {
+		if c.collects(name) {
+			fetches = append(fetches, nginxPlusEndpoints[name])
+		}
+	}
+	if c.apiClient != nil && c.collects("ssl") {
+		fetches = append(fetches, getSSLFailures)
+	}
+
+	// every fetch sets its own fields of the stats
+	stats := &nginxPlusStats{}
+	errs := make([]error, len(fetches))
+	var wg sync.WaitGroup
+	for i, fetch := range fetches {
+		wg.Add(1)
+		go func(i int, fetch func(ctx context.Context, c *NginxPlusCollector, stats *nginxPlusStats) error) {
+			defer wg.Done()
+			errs[i] = fetch(ctx, c, stats)
+		}(i, fetch)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("failed to get stats: %w", err)
+		}
+	}
+	return stats, nil
+}
@@ -0,0 +1,95 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	plusapi "github.com/nginxinc/nginx-prometheus-exporter/client/plus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxPlusCollectorEndpoints(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		switch r.URL.Path {
+		case "/api/9/connections":
+			_, _ = w.Write([]byte(`{"accepted":10,"dropped":0,"active":2,"idle":1}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+
+	if err := collector.SetEndpoints([]string{"connections", "upstream"}); err == nil {
+		t.Error("SetEndpoints() error = nil, want an error for an unknown endpoint")
+	}
+	if err := collector.SetEndpoints([]string{"connections"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+
+	if got := testutil.CollectAndCount(collector); got != 5 {
+		t.Errorf("collected metrics = %v, want 5 (up and the connections)", got)
+	}
+	if len(paths) != 1 || paths[0] != "/api/9/connections" {
+		t.Errorf("fetched paths = %v, want only /api/9/connections", paths)
+	}
+}
+
+func TestNginxPlusCollectorTimeout(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/9/ssl":
+			_, _ = w.Write([]byte(`{"handshakes":1,"no_common_cipher":2}`))
+		case "/api/9/license":
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+	collector.SetAPIClient(plusapi.NewNginxClient(server.Client(), server.URL+"/api", 9))
+	collector.SetTimeout(100 * time.Millisecond)
+	if err := collector.SetEndpoints([]string{"ssl", "license"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+
+	start := time.Now()
+	const want = `# HELP nginxplus_ssl_no_common_cipher SSL handshakes failed because of no shared cipher
+# TYPE nginxplus_ssl_no_common_cipher counter
+nginxplus_ssl_no_common_cipher 2
+# HELP nginxplus_up Status of the last metric scrape
+# TYPE nginxplus_up gauge
+nginxplus_up 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxplus_up", "nginxplus_ssl_no_common_cipher"); err != nil {
+		t.Error(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("scrape took %v, want the license request canceled after the timeout", elapsed)
+	}
+}
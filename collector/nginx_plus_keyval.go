@@ -0,0 +1,101 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// NginxPlusKeyvalCollector collects the usage of the NGINX Plus keyval zones. It implements prometheus.Collector interface.
+// All the key-value pairs of the zones are fetched on every collect, so it is separate from NginxPlusCollector.
+type NginxPlusKeyvalCollector struct {
+	nginxClient         *plusclient.NginxClient
+	keyvalMetrics       map[string]*prometheus.Desc
+	streamKeyvalMetrics map[string]*prometheus.Desc
+	infoZones           map[string]bool
+	mutex               sync.Mutex
+	logger              log.Logger
+}
+
+// NewNginxPlusKeyvalCollector creates an NginxPlusKeyvalCollector.
+func NewNginxPlusKeyvalCollector(nginxClient *plusclient.NginxClient, namespace string, constLabels map[string]string, logger log.Logger) *NginxPlusKeyvalCollector {
+	return &NginxPlusKeyvalCollector{
+		nginxClient: nginxClient,
+		logger:      logger,
+		keyvalMetrics: map[string]*prometheus.Desc{
+			"keys":  newKeyvalMetric(namespace, "keyval", "keys", "Current number of keys in the keyval zone", constLabels),
+			"bytes": newKeyvalMetric(namespace, "keyval", "bytes", "Current total size of the keys and values in the keyval zone in bytes", constLabels),
+			"info":  prometheus.NewDesc(prometheus.BuildFQName(namespace, "keyval", "info"), "Key-value pair of the keyval zone, the value is always 1", []string{"zone", "key", "value"}, constLabels),
+		},
+		streamKeyvalMetrics: map[string]*prometheus.Desc{
+			"keys":  newKeyvalMetric(namespace, "stream_keyval", "keys", "Current number of keys in the keyval zone", constLabels),
+			"bytes": newKeyvalMetric(namespace, "stream_keyval", "bytes", "Current total size of the keys and values in the keyval zone in bytes", constLabels),
+			"info":  prometheus.NewDesc(prometheus.BuildFQName(namespace, "stream_keyval", "info"), "Key-value pair of the keyval zone, the value is always 1", []string{"zone", "key", "value"}, constLabels),
+		},
+	}
+}
+
+// SetInfoZones sets the keyval zones whose key-value pairs are exported as info metrics.
+func (c *NginxPlusKeyvalCollector) SetInfoZones(zones []string) {
+	infoZones := make(map[string]bool, len(zones))
+	for _, zone := range zones {
+		infoZones[zone] = true
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.infoZones = infoZones
+}
+
+// Describe sends the super-set of all possible descriptors of NGINX Plus keyval metrics
+// to the provided channel.
+func (c *NginxPlusKeyvalCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.keyvalMetrics {
+		ch <- m
+	}
+	for _, m := range c.streamKeyvalMetrics {
+		ch <- m
+	}
+}
+
+// Collect fetches the keyval zones from NGINX Plus and sends the metrics to the provided channel.
+func (c *NginxPlusKeyvalCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	zones, err := c.nginxClient.GetAllKeyValPairs()
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Error getting keyval zones", "error", err.Error())
+	} else {
+		collectKeyvalZones(ch, c.keyvalMetrics, zones, c.infoZones)
+	}
+
+	streamZones, err := c.nginxClient.GetAllStreamKeyValPairs()
+	if err != nil {
+		// the endpoint does not exist without a stream block in the configuration
+		level.Debug(c.logger).Log("msg", "Error getting stream keyval zones", "error", err.Error())
+	} else {
+		collectKeyvalZones(ch, c.streamKeyvalMetrics, streamZones, c.infoZones)
+	}
+}
+
+func collectKeyvalZones(ch chan<- prometheus.Metric, metrics map[string]*prometheus.Desc, zones plusclient.KeyValPairsByZone, infoZones map[string]bool) {
+	for name, pairs := range zones {
+		var size int
+		for key, value := range pairs {
+			size += len(key) + len(value)
+			if infoZones[name] {
+				ch <- prometheus.MustNewConstMetric(metrics["info"], prometheus.GaugeValue, 1, name, key, value)
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(metrics["keys"], prometheus.GaugeValue, float64(len(pairs)), name)
+		ch <- prometheus.MustNewConstMetric(metrics["bytes"], prometheus.GaugeValue, float64(size), name)
+	}
+}
+
+func newKeyvalMetric(namespace string, subsystem string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, subsystem, metricName), docString, []string{"zone"}, constLabels)
+}
@@ -0,0 +1,307 @@
+package collector
+
+import (
+	"sort"
+
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+)
+
+// overflowName is the server of the upstream servers and the name of the zones that sum the servers and the zones
+// above the limit of the exported ones. A real server or zone with this name is always summed in the overflow.
+const overflowName = "_overflow"
+
+// overflowSum limits the exported members of a group, e.g. the servers of an upstream or the server zones, and sums
+// the members above the limit in the member with the overflowName across collects. The exported members are kept
+// while they exist and the counters of the members that left the overflow are kept in the sum, so that the counters
+// of the overflow do not go down when the members of the group change. The counters of the members before a reset,
+// e.g. when nginx is restarted, are kept in the sum as well.
+type overflowSum[T any] struct {
+	max int
+	// sum sums the members, counters returns a member without its gauges and requests a counter that goes down only
+	// when the counters of the member are reset
+	sum      func([]T) T
+	counters func(T) T
+	requests func(T) uint64
+	// exported and members are the exported members and the members of the overflow of the previous collect
+	exported map[string]bool
+	members  map[string]T
+	departed T
+}
+
+func newOverflowSum[T any](max int, sum func([]T) T, counters func(T) T, requests func(T) uint64) *overflowSum[T] {
+	return &overflowSum[T]{
+		max:      max,
+		sum:      sum,
+		counters: counters,
+		requests: requests,
+		exported: make(map[string]bool),
+		members:  make(map[string]T),
+	}
+}
+
+// limit returns at most max members and the sum of the members above max with the overflowName, and the number of
+// the members above max. The members exported by the previous collect are exported first, then the members with the
+// first names. All the members are returned if the sum is nil.
+func (o *overflowSum[T]) limit(members map[string]T) (map[string]T, int) {
+	if o == nil {
+		return members, 0
+	}
+	exported := make(map[string]bool, o.max)
+	for name := range o.exported {
+		if _, ok := members[name]; ok && name != overflowName {
+			exported[name] = true
+		}
+	}
+	names := make([]string, 0, len(members))
+	for name := range members {
+		if !exported[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	limited := make(map[string]T, o.max+1)
+	overflow := make(map[string]T, len(names))
+	for _, name := range names {
+		if len(exported) < o.max && name != overflowName {
+			exported[name] = true
+		} else {
+			overflow[name] = members[name]
+		}
+	}
+	for name := range exported {
+		limited[name] = members[name]
+	}
+
+	for name, previous := range o.members {
+		if member, ok := overflow[name]; !ok || o.requests(member) < o.requests(previous) {
+			o.departed = o.sum([]T{o.departed, o.counters(previous)})
+		}
+	}
+	o.exported = exported
+	o.members = overflow
+
+	if len(overflow) > 0 {
+		sums := make([]T, 0, len(overflow)+1)
+		for _, member := range overflow {
+			sums = append(sums, member)
+		}
+		limited[overflowName] = o.sum(append(sums, o.departed))
+	}
+	return limited, len(overflow)
+}
+
+// peersByServer returns the peers by their server, the peers with the same server are summed.
+func peersByServer[P any](peers []P, server func(P) string, sum func([]P) P) map[string]P {
+	byServer := make(map[string]P, len(peers))
+	for _, peer := range peers {
+		name := server(peer)
+		if previous, ok := byServer[name]; ok {
+			peer = sum([]P{previous, peer})
+		}
+		byServer[name] = peer
+	}
+	return byServer
+}
+
+// overflowPeer sums the counters of the peers into a peer. The state and the times of the peers cannot be summed and
+// are left empty.
+func overflowPeer(peers []plusclient.Peer) plusclient.Peer {
+	var overflow plusclient.Peer
+	for _, peer := range peers {
+		overflow.Active += peer.Active
+		overflow.MaxConns += peer.MaxConns
+		overflow.Requests += peer.Requests
+		addResponses(&overflow.Responses, peer.Responses)
+		overflow.Sent += peer.Sent
+		overflow.Received += peer.Received
+		overflow.Fails += peer.Fails
+		overflow.Unavail += peer.Unavail
+		addHealthChecks(&overflow.HealthChecks, peer.HealthChecks)
+		addSSL(&overflow.SSL, peer.SSL)
+	}
+	return overflow
+}
+
+// overflowStreamPeer sums the counters of the stream peers like overflowPeer.
+func overflowStreamPeer(peers []plusclient.StreamPeer) plusclient.StreamPeer {
+	var overflow plusclient.StreamPeer
+	for _, peer := range peers {
+		overflow.Active += peer.Active
+		overflow.MaxConns += peer.MaxConns
+		overflow.Connections += peer.Connections
+		overflow.Sent += peer.Sent
+		overflow.Received += peer.Received
+		overflow.Fails += peer.Fails
+		overflow.Unavail += peer.Unavail
+		addHealthChecks(&overflow.HealthChecks, peer.HealthChecks)
+		addSSL(&overflow.SSL, peer.SSL)
+	}
+	return overflow
+}
+
+func overflowServerZone(zones []plusclient.ServerZone) plusclient.ServerZone {
+	var overflow plusclient.ServerZone
+	for _, zone := range zones {
+		overflow.Processing += zone.Processing
+		overflow.Requests += zone.Requests
+		addResponses(&overflow.Responses, zone.Responses)
+		overflow.Discarded += zone.Discarded
+		overflow.Received += zone.Received
+		overflow.Sent += zone.Sent
+		addSSL(&overflow.SSL, zone.SSL)
+	}
+	return overflow
+}
+
+func overflowLocationZone(zones []plusclient.LocationZone) plusclient.LocationZone {
+	var overflow plusclient.LocationZone
+	for _, zone := range zones {
+		overflow.Requests += zone.Requests
+		addResponses(&overflow.Responses, zone.Responses)
+		overflow.Discarded += zone.Discarded
+		overflow.Received += zone.Received
+		overflow.Sent += zone.Sent
+	}
+	return overflow
+}
+
+// overflowCache sums the sizes and the counters of the cache zones. The sum is cold while any of the zones is cold.
+func overflowCache(caches []plusclient.HTTPCache) plusclient.HTTPCache {
+	var overflow plusclient.HTTPCache
+	for _, cache := range caches {
+		overflow.Size += cache.Size
+		overflow.MaxSize += cache.MaxSize
+		overflow.Cold = overflow.Cold || cache.Cold
+		addCacheStats(&overflow.Hit, cache.Hit)
+		addCacheStats(&overflow.Stale, cache.Stale)
+		addCacheStats(&overflow.Updating, cache.Updating)
+		addCacheStats(&overflow.Revalidated, cache.Revalidated)
+		addCacheStats(&overflow.Miss, cache.Miss)
+		addExtendedCacheStats(&overflow.Expired, cache.Expired)
+		addExtendedCacheStats(&overflow.Bypass, cache.Bypass)
+	}
+	return overflow
+}
+
+func peerCounters(peer plusclient.Peer) plusclient.Peer {
+	peer.Active = 0
+	peer.MaxConns = 0
+	return peer
+}
+
+func streamPeerCounters(peer plusclient.StreamPeer) plusclient.StreamPeer {
+	peer.Active = 0
+	peer.MaxConns = 0
+	return peer
+}
+
+func serverZoneCounters(zone plusclient.ServerZone) plusclient.ServerZone {
+	zone.Processing = 0
+	return zone
+}
+
+func locationZoneCounters(zone plusclient.LocationZone) plusclient.LocationZone {
+	return zone
+}
+
+func cacheCounters(cache plusclient.HTTPCache) plusclient.HTTPCache {
+	cache.Size = 0
+	cache.MaxSize = 0
+	cache.Cold = false
+	return cache
+}
+
+func peerRequests(peer plusclient.Peer) uint64 {
+	return peer.Requests
+}
+
+func streamPeerConnections(peer plusclient.StreamPeer) uint64 {
+	return peer.Connections
+}
+
+func serverZoneRequests(zone plusclient.ServerZone) uint64 {
+	return zone.Requests
+}
+
+func locationZoneRequests(zone plusclient.LocationZone) uint64 {
+	return uint64(zone.Requests)
+}
+
+// cacheResponsesTotal returns all the responses of the cache zone, like the total of collectCacheHitRatio.
+func cacheResponsesTotal(cache plusclient.HTTPCache) uint64 {
+	return cache.Hit.Responses + cache.Stale.Responses + cache.Updating.Responses + cache.Revalidated.Responses +
+		cache.Miss.Responses + cache.Expired.Responses + cache.Bypass.Responses
+}
+
+func addCacheStats(sum *plusclient.CacheStats, stats plusclient.CacheStats) {
+	sum.Responses += stats.Responses
+	sum.Bytes += stats.Bytes
+}
+
+func addExtendedCacheStats(sum *plusclient.ExtendedCacheStats, stats plusclient.ExtendedCacheStats) {
+	addCacheStats(&sum.CacheStats, stats.CacheStats)
+	sum.ResponsesWritten += stats.ResponsesWritten
+	sum.BytesWritten += stats.BytesWritten
+}
+
+func addSSL(sum *plusclient.SSL, ssl plusclient.SSL) {
+	sum.Handshakes += ssl.Handshakes
+	sum.HandshakesFailed += ssl.HandshakesFailed
+	sum.SessionReuses += ssl.SessionReuses
+}
+
+func addHealthChecks(sum *plusclient.HealthChecks, healthChecks plusclient.HealthChecks) {
+	sum.Checks += healthChecks.Checks
+	sum.Fails += healthChecks.Fails
+	sum.Unhealthy += healthChecks.Unhealthy
+}
+
+func addResponses(sum *plusclient.Responses, responses plusclient.Responses) {
+	sum.Responses1xx += responses.Responses1xx
+	sum.Responses2xx += responses.Responses2xx
+	sum.Responses3xx += responses.Responses3xx
+	sum.Responses4xx += responses.Responses4xx
+	sum.Responses5xx += responses.Responses5xx
+	sum.Total += responses.Total
+
+	sum.Codes.HTTPContinue += responses.Codes.HTTPContinue
+	sum.Codes.HTTPSwitchingProtocols += responses.Codes.HTTPSwitchingProtocols
+	sum.Codes.HTTPProcessing += responses.Codes.HTTPProcessing
+	sum.Codes.HTTPOk += responses.Codes.HTTPOk
+	sum.Codes.HTTPCreated += responses.Codes.HTTPCreated
+	sum.Codes.HTTPAccepted += responses.Codes.HTTPAccepted
+	sum.Codes.HTTPNoContent += responses.Codes.HTTPNoContent
+	sum.Codes.HTTPPartialContent += responses.Codes.HTTPPartialContent
+	sum.Codes.HTTPSpecialResponse += responses.Codes.HTTPSpecialResponse
+	sum.Codes.HTTPMovedPermanently += responses.Codes.HTTPMovedPermanently
+	sum.Codes.HTTPMovedTemporarily += responses.Codes.HTTPMovedTemporarily
+	sum.Codes.HTTPSeeOther += responses.Codes.HTTPSeeOther
+	sum.Codes.HTTPNotModified += responses.Codes.HTTPNotModified
+	sum.Codes.HTTPTemporaryRedirect += responses.Codes.HTTPTemporaryRedirect
+	sum.Codes.HTTPBadRequest += responses.Codes.HTTPBadRequest
+	sum.Codes.HTTPUnauthorized += responses.Codes.HTTPUnauthorized
+	sum.Codes.HTTPForbidden += responses.Codes.HTTPForbidden
+	sum.Codes.HTTPNotFound += responses.Codes.HTTPNotFound
+	sum.Codes.HTTPNotAllowed += responses.Codes.HTTPNotAllowed
+	sum.Codes.HTTPRequestTimeOut += responses.Codes.HTTPRequestTimeOut
+	sum.Codes.HTTPConflict += responses.Codes.HTTPConflict
+	sum.Codes.HTTPLengthRequired += responses.Codes.HTTPLengthRequired
+	sum.Codes.HTTPPreconditionFailed += responses.Codes.HTTPPreconditionFailed
+	sum.Codes.HTTPRequestEntityTooLarge += responses.Codes.HTTPRequestEntityTooLarge
+	sum.Codes.HTTPRequestURITooLarge += responses.Codes.HTTPRequestURITooLarge
+	sum.Codes.HTTPUnsupportedMediaType += responses.Codes.HTTPUnsupportedMediaType
+	sum.Codes.HTTPRangeNotSatisfiable += responses.Codes.HTTPRangeNotSatisfiable
+	sum.Codes.HTTPTooManyRequests += responses.Codes.HTTPTooManyRequests
+	sum.Codes.HTTPClose += responses.Codes.HTTPClose
+	sum.Codes.HTTPRequestHeaderTooLarge += responses.Codes.HTTPRequestHeaderTooLarge
+	sum.Codes.HTTPSCertError += responses.Codes.HTTPSCertError
+	sum.Codes.HTTPSNoCert += responses.Codes.HTTPSNoCert
+	sum.Codes.HTTPToHTTPS += responses.Codes.HTTPToHTTPS
+	sum.Codes.HTTPClientClosedRequest += responses.Codes.HTTPClientClosedRequest
+	sum.Codes.HTTPInternalServerError += responses.Codes.HTTPInternalServerError
+	sum.Codes.HTTPNotImplemented += responses.Codes.HTTPNotImplemented
+	sum.Codes.HTTPBadGateway += responses.Codes.HTTPBadGateway
+	sum.Codes.HTTPServiceUnavailable += responses.Codes.HTTPServiceUnavailable
+	sum.Codes.HTTPGatewayTimeOut += responses.Codes.HTTPGatewayTimeOut
+	sum.Codes.HTTPInsufficientStorage += responses.Codes.HTTPInsufficientStorage
+}
@@ -0,0 +1,201 @@
+package collector
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/log"
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestOverflowSumLimit(t *testing.T) {
+	t.Parallel()
+
+	overflow := newOverflowSum(2, overflowLocationZone, locationZoneCounters, locationZoneRequests)
+
+	limited, overflows := overflow.limit(map[string]plusclient.LocationZone{"c": {Requests: 3}, "a": {Requests: 1}, "b": {Requests: 2}})
+	if len(limited) != 3 || limited["a"].Requests != 1 || limited["b"].Requests != 2 || limited[overflowName].Requests != 3 || overflows != 1 {
+		t.Errorf("limit() = %v, %v, want the first 2 zones and the sum of the last one", limited, overflows)
+	}
+
+	// a zone before the exported ones is summed in the overflow, the zone that left the overflow is kept in the sum
+	limited, overflows = overflow.limit(map[string]plusclient.LocationZone{"0": {Requests: 4}, "a": {Requests: 5}, "b": {Requests: 6}})
+	if len(limited) != 3 || limited["a"].Requests != 5 || limited["b"].Requests != 6 || limited[overflowName].Requests != 7 || overflows != 1 {
+		t.Errorf("limit() = %v, %v, want the exported zones and the sum of the new zone and the removed one", limited, overflows)
+	}
+
+	// a zone of the overflow takes the place of a removed exported zone, a real _overflow zone is summed in the overflow
+	limited, overflows = overflow.limit(map[string]plusclient.LocationZone{"0": {Requests: 8}, "b": {Requests: 9}, overflowName: {Requests: 10}})
+	if len(limited) != 3 || limited["0"].Requests != 8 || limited["b"].Requests != 9 || limited[overflowName].Requests != 17 || overflows != 1 {
+		t.Errorf("limit() = %v, %v, want the zones that remain and the sum of the _overflow zone and the ones that left the overflow", limited, overflows)
+	}
+
+	var none *overflowSum[plusclient.LocationZone]
+	if limited, overflows := none.limit(map[string]plusclient.LocationZone{"a": {}, overflowName: {}}); len(limited) != 2 || overflows != 0 {
+		t.Errorf("limit() of no sum = %v, %v, want all the zones", limited, overflows)
+	}
+}
+
+func TestOverflowSumReset(t *testing.T) {
+	t.Parallel()
+
+	overflow := newOverflowSum(1, overflowServerZone, serverZoneCounters, serverZoneRequests)
+
+	if limited, _ := overflow.limit(map[string]plusclient.ServerZone{"a": {Requests: 1}, "b": {Processing: 2, Requests: 10}, "c": {Processing: 3, Requests: 20}}); limited[overflowName].Requests != 30 || limited[overflowName].Processing != 5 {
+		t.Errorf("limit() = %v, want the sums of the last 2 zones", limited)
+	}
+
+	// nginx is restarted, the counters before the reset are kept and the gauges are not
+	limited, _ := overflow.limit(map[string]plusclient.ServerZone{"a": {Requests: 1}, "b": {Processing: 1, Requests: 2}, "c": {Processing: 1, Requests: 25}})
+	if limited[overflowName].Requests != 37 || limited[overflowName].Processing != 2 {
+		t.Errorf("limit() after a restart = %v, want the counters of the reset zone before the reset in the sum", limited)
+	}
+}
+
+func TestPeersByServer(t *testing.T) {
+	t.Parallel()
+
+	peers := peersByServer([]plusclient.Peer{{Server: "10.0.0.1:80", Requests: 1}, {Server: "10.0.0.2:80", Requests: 2}, {Server: "10.0.0.1:80", Requests: 3}},
+		func(peer plusclient.Peer) string { return peer.Server }, overflowPeer)
+	if len(peers) != 2 || peers["10.0.0.1:80"].Requests != 4 || peers["10.0.0.2:80"].Requests != 2 {
+		t.Errorf("peersByServer() = %v, want the peers with the same server summed", peers)
+	}
+}
+
+func TestOverflowPeer(t *testing.T) {
+	t.Parallel()
+
+	peers := []plusclient.Peer{
+		{Server: "10.0.0.3:80", State: "up", Requests: 10, Sent: 100, HeaderTime: 5},
+		{Server: "10.0.0.4:80", State: "down", Requests: 5, Sent: 50, HeaderTime: 7},
+	}
+	peers[0].Responses.Responses2xx = 9
+	peers[0].Responses.Codes.HTTPOk = 9
+	peers[1].Responses.Responses2xx = 4
+	peers[1].Responses.Codes.HTTPOk = 4
+
+	overflow := overflowPeer(peers)
+	if overflow.State != "" || overflow.HeaderTime != 0 {
+		t.Errorf("overflowPeer() = %+v, want no state and no times", overflow)
+	}
+	if overflow.Requests != 15 || overflow.Sent != 150 || overflow.Responses.Responses2xx != 13 || overflow.Responses.Codes.HTTPOk != 13 {
+		t.Errorf("overflowPeer() = %+v, want the sums of the counters", overflow)
+	}
+}
+
+func TestNginxPlusCollectorOverflowCaches(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"a":{"size":1,"hit":{"responses":1}},"b":{"size":2,"hit":{"responses":2}},"c":{"size":4,"hit":{"responses":4}}}`))
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+	if err := collector.SetEndpoints([]string{"caches"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+	collector.SetMaxPeers(1)
+
+	const want = `# HELP nginxplus_cache_size Current size of the cache
+# TYPE nginxplus_cache_size gauge
+nginxplus_cache_size{zone="_overflow"} 6
+nginxplus_cache_size{zone="a"} 1
+# HELP nginxplus_cache_zones_overflow Cache zones that are not exported because of the limit of the exported zones
+# TYPE nginxplus_cache_zones_overflow gauge
+nginxplus_cache_zones_overflow 2
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxplus_cache_size", "nginxplus_cache_zones_overflow"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNginxPlusCollectorOverflowMembership(t *testing.T) {
+	t.Parallel()
+
+	var bodies = []string{
+		`{"peers":[{"id":0,"server":"10.0.0.1:80","requests":1},{"id":1,"server":"10.0.0.2:80","requests":2},{"id":2,"server":"10.0.0.3:80","requests":4}]}`,
+		// a server is added before the exported one and the last one is removed
+		`{"peers":[{"id":3,"server":"10.0.0.0:80","requests":1},{"id":0,"server":"10.0.0.1:80","requests":8},{"id":1,"server":"10.0.0.2:80","requests":16}]}`,
+	}
+	var scrape atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"backend":` + bodies[scrape.Load()] + `}`))
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+	if err := collector.SetEndpoints([]string{"upstreams"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+	collector.SetMaxPeers(2)
+
+	for i, want := range []string{`# HELP nginxplus_upstream_server_requests Total client requests
+# TYPE nginxplus_upstream_server_requests counter
+nginxplus_upstream_server_requests{server="10.0.0.1:80",upstream="backend"} 1
+nginxplus_upstream_server_requests{server="10.0.0.2:80",upstream="backend"} 2
+nginxplus_upstream_server_requests{server="_overflow",upstream="backend"} 4
+`, `# HELP nginxplus_upstream_server_requests Total client requests
+# TYPE nginxplus_upstream_server_requests counter
+nginxplus_upstream_server_requests{server="10.0.0.1:80",upstream="backend"} 8
+nginxplus_upstream_server_requests{server="10.0.0.2:80",upstream="backend"} 16
+nginxplus_upstream_server_requests{server="_overflow",upstream="backend"} 5
+`} {
+		scrape.Store(int32(i))
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxplus_upstream_server_requests"); err != nil {
+			t.Errorf("scrape %v: %v", i, err)
+		}
+	}
+}
+
+func TestNginxPlusCollectorOverflowRestart(t *testing.T) {
+	t.Parallel()
+
+	var bodies = []string{
+		`{"a":{"requests":1,"received":1},"b":{"requests":2,"received":2},"c":{"requests":4,"received":4}}`,
+		// nginx is restarted and all the counters are reset
+		`{"a":{"requests":1,"received":1},"b":{"requests":1,"received":1},"c":{"requests":1,"received":1}}`,
+	}
+	var scrape atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(bodies[scrape.Load()]))
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+	if err := collector.SetEndpoints([]string{"server_zones"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+	collector.SetMaxPeers(1)
+
+	for i, want := range []string{`# HELP nginxplus_server_zone_received Bytes received from clients
+# TYPE nginxplus_server_zone_received counter
+nginxplus_server_zone_received{server_zone="_overflow"} 6
+nginxplus_server_zone_received{server_zone="a"} 1
+`, `# HELP nginxplus_server_zone_received Bytes received from clients
+# TYPE nginxplus_server_zone_received counter
+nginxplus_server_zone_received{server_zone="_overflow"} 8
+nginxplus_server_zone_received{server_zone="a"} 1
+`} {
+		scrape.Store(int32(i))
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxplus_server_zone_received"); err != nil {
+			t.Errorf("scrape %v: %v", i, err)
+		}
+	}
+}
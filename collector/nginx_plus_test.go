@@ -0,0 +1,113 @@
+package collector
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-kit/log"
+	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxPlusCollectorCacheHitRatio(t *testing.T) {
+	t.Parallel()
+
+	caches := []string{
+		`{"http_cache":{"hit":{"responses":10},"miss":{"responses":10}}}`,
+		`{"http_cache":{"hit":{"responses":16},"stale":{"responses":2},"miss":{"responses":12}}}`,
+		`{"http_cache":{"hit":{"responses":16},"stale":{"responses":2},"miss":{"responses":12}}}`,
+		`{"http_cache":{"hit":{"responses":1},"miss":{"responses":3}}}`,
+	}
+	var scrapes atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(caches[scrapes.Add(1)-1]))
+	}))
+	defer server.Close()
+
+	client, err := plusclient.NewNginxClient(server.URL+"/api", plusclient.WithHTTPClient(server.Client()))
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	collector := NewNginxPlusCollector(client, "nginxplus", NewVariableLabelNames(nil, nil, nil, nil, nil, nil), nil, log.NewNopLogger())
+	if err := collector.SetEndpoints([]string{"caches"}); err != nil {
+		t.Fatalf("SetEndpoints() error = %v", err)
+	}
+	collector.EnableCacheHitRatio()
+
+	const help = "# HELP nginxplus_cache_hit_ratio Ratio of the responses read from the cache to all the responses since the previous scrape\n" +
+		"# TYPE nginxplus_cache_hit_ratio gauge\n"
+	expected := []string{
+		// no previous scrape
+		"",
+		// 8 hits of 10 responses
+		help + `nginxplus_cache_hit_ratio{zone="http_cache"} 0.8` + "\n",
+		// no responses since the previous scrape
+		"",
+		// the counters were reset
+		help + `nginxplus_cache_hit_ratio{zone="http_cache"} 0.25` + "\n",
+	}
+	for i, want := range expected {
+		if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxplus_cache_hit_ratio"); err != nil {
+			t.Errorf("scrape %v: %v", i, err)
+		}
+	}
+}
+
+func TestNewPlusClient(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		versions string
+		want     int
+		wantErr  bool
+	}{
+		{
+			"Highest version supported by the client",
+			"[1,2,3,4,5,6,7,8,9]",
+			9,
+			false,
+		},
+		{
+			"Older NGINX Plus",
+			"[1,2,3,4,5,6]",
+			6,
+			false,
+		},
+		{
+			"Newer NGINX Plus",
+			"[7,8,9,10,11]",
+			9,
+			false,
+		},
+		{
+			"No version supported by the client",
+			"[1,2,3]",
+			0,
+			true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.versions))
+			}))
+			defer server.Close()
+
+			plusClient, err := newPlusClient(context.Background(), server.Client(), server.URL+"/api")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newPlusClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && plusClient.Version() != tt.want {
+				t.Errorf("newPlusClient() version = %v, want %v", plusClient.Version(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// nginxProcessTitle matches the process titles nginx sets, e.g. nginx: worker process or nginx: cache manager process.
+var nginxProcessTitle = regexp.MustCompile(`^nginx: (master|worker|cache manager|cache loader) process( is shutting down)?`)
+
+// NginxProcessCollector collects resource usage of the nginx master and worker processes from procfs.
+// It implements prometheus.Collector interface.
+type NginxProcessCollector struct {
+	fs             procfs.FS
+	processMetrics map[string]*prometheus.Desc
+	// masters are the masters found by the previous collect, nil before the first one
+	masters    map[processID]nginxMaster
+	cpuTimes   *processCPUTimes
+	reloads    float64
+	upgrades   float64
+	lastReload float64
+	mutex      sync.Mutex
+	logger     log.Logger
+}
+
+// nginxMaster is a master process with the start times of its workers that are not shutting down.
+type nginxMaster struct {
+	ppid    int
+	workers map[processID]float64
+}
+
+type nginxProcesses struct {
+	count         int
+	residentBytes float64
+	openFDs       float64
+}
+
+// NewNginxProcessCollector creates an NginxProcessCollector which reads processes from the procfs mounted at procfsPath.
+func NewNginxProcessCollector(procfsPath string, namespace string, constLabels map[string]string, logger log.Logger) (*NginxProcessCollector, error) {
+	fs, err := procfs.NewFS(procfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NginxProcessCollector{
+		fs:       fs,
+		cpuTimes: newProcessCPUTimes(),
+		logger:   logger,
+		processMetrics: map[string]*prometheus.Desc{
+			"processes":                     newNginxProcessMetric(namespace, "processes", "Processes found in procfs", constLabels),
+			"process_resident_memory_bytes": newNginxProcessMetric(namespace, "process_resident_memory_bytes", "Resident memory size of the processes in bytes", constLabels),
+			"process_cpu_seconds_total":     newNginxProcessMetric(namespace, "process_cpu_seconds_total", "Total user and system CPU time spent by the processes in seconds", constLabels),
+			"process_open_fds":              newNginxProcessMetric(namespace, "process_open_fds", "Open file descriptors of the processes", constLabels),
+			"master_start_time_seconds":     newGlobalMetric(namespace, "master_start_time_seconds", "Start time of the master process since unix epoch in seconds", constLabels),
+			"reloads_total":                 newGlobalMetric(namespace, "reloads_total", "Configuration reloads detected since the exporter start", constLabels),
+			"binary_upgrades_total":         newGlobalMetric(namespace, "binary_upgrades_total", "Binary upgrades detected since the exporter start", constLabels),
+			"last_reload_timestamp_seconds": newGlobalMetric(namespace, "last_reload_timestamp_seconds", "Start time of the workers of the last reload or binary upgrade since unix epoch in seconds", constLabels),
+			"old_workers":                   newGlobalMetric(namespace, "old_workers", "Workers of a previous configuration that are still shutting down", constLabels),
+		},
+	}, nil
+}
+
+// Describe sends the super-set of all possible descriptors of nginx process metrics
+// to the provided channel.
+func (c *NginxProcessCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.processMetrics {
+		ch <- m
+	}
+}
+
+// Collect reads the nginx processes from procfs and sends the metrics to the provided channel.
+func (c *NginxProcessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	procs, err := c.fs.AllProcs()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error reading processes", "error", err.Error())
+		return
+	}
+
+	processes := make(map[string]*nginxProcesses)
+	masters := make(map[processID]nginxMaster)
+	workers := make(map[int]map[processID]float64)
+	var masterStartTime float64
+	var oldWorkers int
+	for _, p := range procs {
+		// processes may exit while being read, errors are expected and skipped
+		cmdline, err := p.CmdLine()
+		if err != nil {
+			continue
+		}
+		process, shuttingDown, ok := parseNginxProcessType(cmdline)
+		if !ok {
+			continue
+		}
+		stat, err := p.Stat()
+		if err != nil {
+			continue
+		}
+
+		group, ok := processes[process]
+		if !ok {
+			group = &nginxProcesses{}
+			processes[process] = group
+		}
+		group.count++
+		group.residentBytes += float64(stat.ResidentMemory())
+		c.cpuTimes.observe(p.PID, stat.Starttime, process, stat.CPUTime())
+		if fds, err := p.FileDescriptorsLen(); err == nil {
+			group.openFDs += float64(fds)
+		}
+
+		startTime, err := stat.StartTime()
+		if err != nil {
+			continue
+		}
+		switch {
+		case process == "master":
+			masters[processID{pid: p.PID, startTime: stat.Starttime}] = nginxMaster{ppid: stat.PPID}
+			// the master started last is the new one during a binary upgrade
+			if startTime > masterStartTime {
+				masterStartTime = startTime
+			}
+		case process == "worker" && shuttingDown:
+			oldWorkers++
+		case process == "worker":
+			if workers[stat.PPID] == nil {
+				workers[stat.PPID] = make(map[processID]float64)
+			}
+			workers[stat.PPID][processID{pid: p.PID, startTime: stat.Starttime}] = startTime
+		}
+	}
+	for process, master := range masters {
+		master.workers = workers[process.pid]
+		masters[process] = master
+	}
+
+	var reloads, upgrades int
+	if c.masters != nil {
+		reloads, upgrades = countReloads(c.masters, masters)
+	}
+	c.reloads += float64(reloads)
+	c.upgrades += float64(upgrades)
+	c.masters = masters
+	// the last reload before the exporter start is unknown, the newest workers are the closest guess.
+	// A master that started after the last reload was restarted.
+	if c.lastReload == 0 || reloads > 0 || upgrades > 0 || masterStartTime > c.lastReload {
+		c.lastReload = newestWorkerStartTime(masters)
+	}
+
+	// the CPU time of the exited processes is kept, also while no processes of the group are running
+	for process, seconds := range c.cpuTimes.totals() {
+		ch <- prometheus.MustNewConstMetric(c.processMetrics["process_cpu_seconds_total"],
+			prometheus.CounterValue, seconds, process)
+	}
+	for process, group := range processes {
+		ch <- prometheus.MustNewConstMetric(c.processMetrics["processes"],
+			prometheus.GaugeValue, float64(group.count), process)
+		ch <- prometheus.MustNewConstMetric(c.processMetrics["process_resident_memory_bytes"],
+			prometheus.GaugeValue, group.residentBytes, process)
+		ch <- prometheus.MustNewConstMetric(c.processMetrics["process_open_fds"],
+			prometheus.GaugeValue, group.openFDs, process)
+	}
+	ch <- prometheus.MustNewConstMetric(c.processMetrics["reloads_total"],
+		prometheus.CounterValue, c.reloads)
+	ch <- prometheus.MustNewConstMetric(c.processMetrics["binary_upgrades_total"],
+		prometheus.CounterValue, c.upgrades)
+	if len(masters) == 0 {
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(c.processMetrics["master_start_time_seconds"],
+		prometheus.GaugeValue, masterStartTime)
+	ch <- prometheus.MustNewConstMetric(c.processMetrics["old_workers"],
+		prometheus.GaugeValue, float64(oldWorkers))
+	if c.lastReload > 0 {
+		ch <- prometheus.MustNewConstMetric(c.processMetrics["last_reload_timestamp_seconds"],
+			prometheus.GaugeValue, c.lastReload)
+	}
+}
+
+// countReloads compares the masters of two collects. A master that kept running but replaced all its workers was
+// reloaded. A new master started by a previous master is a binary upgrade.
+func countReloads(previous, current map[processID]nginxMaster) (reloads int, upgrades int) {
+	previousPIDs := make(map[int]bool)
+	for process := range previous {
+		previousPIDs[process.pid] = true
+	}
+
+	for process, master := range current {
+		previousMaster, ok := previous[process]
+		if !ok {
+			if previousPIDs[master.ppid] {
+				upgrades++
+			}
+			continue
+		}
+		if len(previousMaster.workers) == 0 || len(master.workers) == 0 {
+			continue
+		}
+		replaced := true
+		for worker := range master.workers {
+			if _, ok := previousMaster.workers[worker]; ok {
+				replaced = false
+				break
+			}
+		}
+		if replaced {
+			reloads++
+		}
+	}
+	return reloads, upgrades
+}
+
+// newestWorkerStartTime returns the start time of the newest worker that is not shutting down.
+func newestWorkerStartTime(masters map[processID]nginxMaster) float64 {
+	var newest float64
+	for _, master := range masters {
+		for _, startTime := range master.workers {
+			if startTime > newest {
+				newest = startTime
+			}
+		}
+	}
+	return newest
+}
+
+// parseNginxProcessType returns the type of an nginx process: master, worker, cache_manager or cache_loader, and whether
+// the process is shutting down after a reload.
+func parseNginxProcessType(cmdline []string) (string, bool, bool) {
+	if len(cmdline) == 0 {
+		return "", false, false
+	}
+	matches := nginxProcessTitle.FindStringSubmatch(strings.Join(cmdline, " "))
+	if matches == nil {
+		return "", false, false
+	}
+	return strings.ReplaceAll(matches[1], " ", "_"), matches[2] != "", true
+}
+
+func newNginxProcessMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "", metricName), docString, []string{"process"}, constLabels)
+}
+
+// newNginxProcessFactory registers the flags of the NGINX process metrics, read from procfs on the host of NGINX.
+func newNginxProcessFactory() Factory {
+	var enabled *bool
+	var procfsPath *string
+	return Factory{
+		Name: "process",
+		Flags: func(app *kingpin.Application) {
+			enabled = app.Flag("nginx.process-metrics", "Export resource usage of the NGINX and NGINX Plus master and worker processes read from procfs. The exporter must run on the same host as NGINX.").Default("false").Envar("NGINX_PROCESS_METRICS").Bool()
+			procfsPath = app.Flag("nginx.procfs-path", "Path to the procfs mount point used for the NGINX process metrics.").Default("/proc").Envar("NGINX_PROCFS_PATH").String()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if !*enabled || env.Unit {
+				return nil, nil
+			}
+			c, err := NewNginxProcessCollector(*procfsPath, "nginx", env.ConstLabels, env.Logger)
+			if err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
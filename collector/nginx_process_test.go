@@ -0,0 +1,126 @@
+package collector
+
+import (
+	"testing"
+)
+
+func TestParseNginxProcessType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		cmdline          []string
+		wantType         string
+		wantShuttingDown bool
+		wantOk           bool
+	}{
+		{
+			name:     "master process",
+			cmdline:  []string{"nginx: master process /usr/sbin/nginx -g daemon off;"},
+			wantType: "master",
+			wantOk:   true,
+		},
+		{
+			name:     "worker process with padded title",
+			cmdline:  []string{"nginx: worker process", "", ""},
+			wantType: "worker",
+			wantOk:   true,
+		},
+		{
+			name:             "worker process shutting down",
+			cmdline:          []string{"nginx: worker process is shutting down"},
+			wantType:         "worker",
+			wantShuttingDown: true,
+			wantOk:           true,
+		},
+		{
+			name:     "cache manager process",
+			cmdline:  []string{"nginx: cache manager process"},
+			wantType: "cache_manager",
+			wantOk:   true,
+		},
+		{
+			name:    "exporter process",
+			cmdline: []string{"nginx-prometheus-exporter", "-nginx.process-metrics"},
+			wantOk:  false,
+		},
+		{
+			name:    "kernel thread",
+			cmdline: []string{},
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			process, shuttingDown, ok := parseNginxProcessType(tt.cmdline)
+			if ok != tt.wantOk {
+				t.Errorf("parseNginxProcessType() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if process != tt.wantType {
+				t.Errorf("parseNginxProcessType() = %v, want %v", process, tt.wantType)
+			}
+			if shuttingDown != tt.wantShuttingDown {
+				t.Errorf("parseNginxProcessType() shutting down = %v, want %v", shuttingDown, tt.wantShuttingDown)
+			}
+		})
+	}
+}
+
+func TestCountReloads(t *testing.T) {
+	t.Parallel()
+
+	master := processID{pid: 1, startTime: 100}
+	workers := map[processID]float64{{pid: 2, startTime: 100}: 1, {pid: 3, startTime: 100}: 1}
+	tests := []struct {
+		name         string
+		previous     map[processID]nginxMaster
+		current      map[processID]nginxMaster
+		wantReloads  int
+		wantUpgrades int
+	}{
+		{
+			name:     "same workers",
+			previous: map[processID]nginxMaster{master: {workers: workers}},
+			current:  map[processID]nginxMaster{master: {workers: workers}},
+		},
+		{
+			name:     "respawned worker",
+			previous: map[processID]nginxMaster{master: {workers: workers}},
+			current: map[processID]nginxMaster{master: {workers: map[processID]float64{
+				{pid: 2, startTime: 100}: 1, {pid: 4, startTime: 200}: 2,
+			}}},
+		},
+		{
+			name:     "reload",
+			previous: map[processID]nginxMaster{master: {workers: workers}},
+			current: map[processID]nginxMaster{master: {workers: map[processID]float64{
+				{pid: 4, startTime: 200}: 2, {pid: 5, startTime: 200}: 2,
+			}}},
+			wantReloads: 1,
+		},
+		{
+			name:     "binary upgrade",
+			previous: map[processID]nginxMaster{master: {workers: workers}},
+			current: map[processID]nginxMaster{
+				master:                   {workers: workers},
+				{pid: 6, startTime: 300}: {ppid: 1, workers: map[processID]float64{{pid: 7, startTime: 300}: 3}},
+			},
+			wantUpgrades: 1,
+		},
+		{
+			name:     "restart",
+			previous: map[processID]nginxMaster{master: {workers: workers}},
+			current: map[processID]nginxMaster{
+				{pid: 6, startTime: 300}: {ppid: 0, workers: map[processID]float64{{pid: 7, startTime: 300}: 3}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reloads, upgrades := countReloads(tt.previous, tt.current)
+			if reloads != tt.wantReloads || upgrades != tt.wantUpgrades {
+				t.Errorf("countReloads() = %v, %v, want %v, %v", reloads, upgrades, tt.wantReloads, tt.wantUpgrades)
+			}
+		})
+	}
+}
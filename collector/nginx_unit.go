@@ -1,12 +1,19 @@
 package collector
 
 import (
-	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
+	"context"
+	"encoding/json"
+	"hash/fnv"
+	"strings"
 	"sync"
+	"time"
+
+	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
 )
 
 // NginxUnitCollector collects NGINX metrics. It implements prometheus.Collector interface.
@@ -14,37 +21,142 @@ type NginxUnitCollector struct {
 	nginxClient        *unitclient.NginxClient
 	metrics            map[string]*prometheus.Desc
 	applicationMetrics map[string]*prometheus.Desc
-	upMetric           prometheus.Gauge
-	mutex              sync.Mutex
-	logger             log.Logger
+	listenerMetrics    map[string]*prometheus.Desc
+	routeMetrics       map[string]*prometheus.Desc
+	upstreamMetrics    map[string]*prometheus.Desc
+	metricGroups       UnitMetricGroups
+	timeout            time.Duration
+	configHash         uint32
+	configLastChange   time.Time
+	// apiDetected is set once the shape of the status is detected, applicationRequestsTotal is set if the status
+	// reports the request totals of the applications
+	apiDetected              bool
+	applicationRequestsTotal bool
+	upMetric                 prometheus.Gauge
+	scrapeErrors             *prometheus.CounterVec
+	lastError                *prometheus.Desc
+	mutex                    sync.Mutex
+	logger                   log.Logger
+}
+
+// applicationLabelNames are the labels taken from the application configuration and added to the application metrics.
+var applicationLabelNames = []string{"type", "user", "group"}
+
+// UnitMetricGroups holds the groups of NGINX Unit metrics enabled for the collector.
+type UnitMetricGroups struct {
+	Connections  bool
+	Requests     bool
+	Applications bool
+	Listeners    bool
+	Routes       bool
+	Config       bool
+	Upstreams    bool
+	Certificates bool
+}
+
+// NewUnitMetricGroups creates a new struct for UnitMetricGroups for the collector
+func NewUnitMetricGroups(connections bool, requests bool, applications bool, listeners bool, routes bool, config bool, upstreams bool, certificates bool) UnitMetricGroups {
+	return UnitMetricGroups{
+		Connections:  connections,
+		Requests:     requests,
+		Applications: applications,
+		Listeners:    listeners,
+		Routes:       routes,
+		Config:       config,
+		Upstreams:    upstreams,
+		Certificates: certificates,
+	}
 }
 
-// NewNginxUnitCollector creates an NewNginxUnitCollector.
-func NewNginxUnitCollector(nginxClient *unitclient.NginxClient, namespace string, constLabels map[string]string, logger log.Logger) *NginxUnitCollector {
-	return &NginxUnitCollector{
-		nginxClient: nginxClient,
-		logger:      logger,
+// NewNginxUnitCollector creates an NewNginxUnitCollector. The timeout limits the time of all the control API
+// requests of a single scrape. The status is fetched once to detect the metrics that the NGINX Unit version reports.
+func NewNginxUnitCollector(nginxClient *unitclient.NginxClient, namespace string, metricGroups UnitMetricGroups, timeout time.Duration, constLabels map[string]string, logger log.Logger) *NginxUnitCollector {
+	c := &NginxUnitCollector{
+		nginxClient:  nginxClient,
+		logger:       logger,
+		metricGroups: metricGroups,
+		timeout:      timeout,
 		metrics: map[string]*prometheus.Desc{
 			"connections_accepted": newGlobalMetric(namespace, "connections_accepted", "Accepted client connections", constLabels),
 			"connections_active":   newGlobalMetric(namespace, "connections_active", "Active client connections", constLabels),
 			"connections_idle":     newGlobalMetric(namespace, "connections_idle", "Idle client connections", constLabels),
 			"connections_closed":   newGlobalMetric(namespace, "connections_closed", "Closed client connections", constLabels),
 			"http_requests_total":  newGlobalMetric(namespace, "http_requests_total", "Total http requests", constLabels),
+			"build_info":           prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "build_info"), "NGINX Unit build information", []string{"version"}, constLabels),
+			"module_info":          prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "module_info"), "Loaded language module", []string{"module", "version"}, constLabels),
+			"applications_total":   newGlobalMetric(namespace, "applications_total", "Configured applications", constLabels),
+			"listeners_total":      newGlobalMetric(namespace, "listeners_total", "Configured listeners", constLabels),
+			"routes_total":         newGlobalMetric(namespace, "routes_total", "Configured routes", constLabels),
+			"certificate_expiry":   prometheus.NewDesc(prometheus.BuildFQName(namespace, "certificate", "expiry_timestamp_seconds"), "Time the certificate bundle expires, the earliest expiry of the chain", []string{"name"}, constLabels),
+			"config_hash":          newGlobalMetric(namespace, "config_hash", "Hash of the configuration", constLabels),
+			"config_last_change":   newGlobalMetric(namespace, "config_last_change_timestamp_seconds", "Time the exporter noticed the last configuration change", constLabels),
 		},
 		applicationMetrics: map[string]*prometheus.Desc{
-			"processes_running":  newApplicationServerMetric(namespace, "processes_running", "Application processes running", []string{}, constLabels),
-			"processes_starting": newApplicationServerMetric(namespace, "processes_starting", "Application processes starting", []string{}, constLabels),
-			"processes_idle":     newApplicationServerMetric(namespace, "processes_idle", "Application processes idle", []string{}, constLabels),
-			"requests_active":    newApplicationServerMetric(namespace, "requests_active", "Active requests", []string{}, constLabels),
+			"processes_running":  newApplicationServerMetric(namespace, "processes_running", "Application processes running", applicationLabelNames, constLabels),
+			"processes_starting": newApplicationServerMetric(namespace, "processes_starting", "Application processes starting", applicationLabelNames, constLabels),
+			"processes_idle":     newApplicationServerMetric(namespace, "processes_idle", "Application processes idle", applicationLabelNames, constLabels),
+			"processes_max":      newApplicationServerMetric(namespace, "processes_max", "Maximum number of application processes", applicationLabelNames, constLabels),
+			"processes_spare":    newApplicationServerMetric(namespace, "processes_spare", "Number of idle application processes kept running", applicationLabelNames, constLabels),
+			"requests_active":    newApplicationServerMetric(namespace, "requests_active", "Active requests", applicationLabelNames, constLabels),
+			"requests_total":     newApplicationServerMetric(namespace, "requests_total", "Total requests", applicationLabelNames, constLabels),
+			"listeners":          newApplicationServerMetric(namespace, "listeners", "Listeners passing requests directly to the application", applicationLabelNames, constLabels),
+			"routes":             newApplicationServerMetric(namespace, "routes", "Route steps passing requests to the application", applicationLabelNames, constLabels),
+			"isolation_info":     newApplicationServerMetric(namespace, "isolation_info", "Isolation settings of the application", []string{"namespaces", "rootfs", "cgroup"}, constLabels),
 		},
-		upMetric: newUpMetric(namespace, constLabels),
+		listenerMetrics: map[string]*prometheus.Desc{
+			"info": newListenerMetric(namespace, "info", "Listener configuration", []string{"pass", "target_type", "target"}, constLabels),
+		},
+		routeMetrics: map[string]*prometheus.Desc{
+			"steps":                newRouteMetric(namespace, "steps", "Steps of the route", constLabels),
+			"missing_applications": newRouteMetric(namespace, "missing_applications", "Route steps passing requests to applications that are not configured", constLabels),
+		},
+		upstreamMetrics: map[string]*prometheus.Desc{
+			"servers": newUpstreamMetric(namespace, "servers", "Servers of the upstream", constLabels),
+			"weight":  newUpstreamServerMetric(namespace, "weight", "Weight of the upstream server", []string{}, constLabels),
+		},
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("unit", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	stats, err := nginxClient.GetStatusWithContext(ctx)
+	if err != nil {
+		level.Warn(logger).Log("msg", "Error getting the status to detect the NGINX Unit API, it is detected on the first scrape", "error", err.Error())
+	} else {
+		c.detectAPI(stats)
+	}
+	return c
+}
+
+// detectAPI picks the metrics by the fields of the status, since older NGINX Unit versions do not report the request
+// totals of the applications. The fields are only known from the status of an application, so the API is detected
+// from the first status with applications and kept, so that the exported series do not change while the exporter runs.
+func (c *NginxUnitCollector) detectAPI(stats *unitclient.Status) {
+	if c.apiDetected || len(stats.Applications) == 0 {
+		return
+	}
+	c.apiDetected = true
+	for _, application := range stats.Applications {
+		if application.Requests.Total != nil {
+			c.applicationRequestsTotal = true
+			return
+		}
 	}
+	level.Info(c.logger).Log("msg", "NGINX Unit does not report the total requests of applications, the metric is not exported", "version", stats.Version)
 }
 
 // Describe sends the super-set of all possible descriptors of NGINX metrics
 // to the provided channel.
 func (c *NginxUnitCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
 
 	for _, m := range c.metrics {
 		ch <- m
@@ -52,6 +164,15 @@ func (c *NginxUnitCollector) Describe(ch chan<- *prometheus.Desc) {
 	for _, m := range c.applicationMetrics {
 		ch <- m
 	}
+	for _, m := range c.listenerMetrics {
+		ch <- m
+	}
+	for _, m := range c.routeMetrics {
+		ch <- m
+	}
+	for _, m := range c.upstreamMetrics {
+		ch <- m
+	}
 }
 
 // Collect fetches metrics from NGINX and sends them to the provided channel.
@@ -59,38 +180,268 @@ func (c *NginxUnitCollector) Collect(ch chan<- prometheus.Metric) {
 	c.mutex.Lock() // To protect metrics from concurrent collects
 	defer c.mutex.Unlock()
 
-	stats, err := c.nginxClient.GetStatus()
-	if err != nil {
+	ctx := context.Background()
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	// the status is required, while the configuration only adds metrics and labels, so its errors are just logged
+	var (
+		stats        *unitclient.Status
+		config       map[string]interface{}
+		certificates unitclient.Certificates
+		upstreams    unitclient.Upstreams
+		listeners    unitclient.Listeners
+		applications unitclient.Applications
+		routes       unitclient.Routes
+	)
+	g, gctx := errgroup.WithContext(ctx)
+	warn := func(msg string, err error) {
+		// the requests are canceled when the status cannot be fetched, which is logged on its own
+		if gctx.Err() == nil {
+			level.Warn(c.logger).Log("msg", msg, "error", err.Error())
+		}
+	}
+	g.Go(func() error {
+		var err error
+		stats, err = c.nginxClient.GetStatusWithContext(gctx)
+		return err
+	})
+	if c.metricGroups.Config {
+		g.Go(func() error {
+			var err error
+			if config, err = c.nginxClient.GetConfigWithContext(gctx); err != nil {
+				warn("Error getting config", err)
+			}
+			return nil
+		})
+	}
+	if c.metricGroups.Certificates {
+		g.Go(func() error {
+			var err error
+			if certificates, err = c.nginxClient.GetCertificatesWithContext(gctx); err != nil {
+				warn("Error getting certificates", err)
+			}
+			return nil
+		})
+	}
+	if c.metricGroups.Upstreams {
+		g.Go(func() error {
+			var err error
+			if upstreams, err = c.nginxClient.GetUpstreamsWithContext(gctx); err != nil {
+				warn("Error getting upstreams", err)
+			}
+			return nil
+		})
+	}
+	if c.metricGroups.Listeners {
+		g.Go(func() error {
+			var err error
+			if listeners, err = c.nginxClient.GetListenersWithContext(gctx); err != nil {
+				warn("Error getting listeners", err)
+			}
+			return nil
+		})
+	}
+	if c.metricGroups.Applications || c.metricGroups.Routes {
+		g.Go(func() error {
+			var err error
+			if applications, err = c.nginxClient.GetApplicationsWithContext(gctx); err != nil {
+				warn("Error getting applications, empty labels will be used instead", err)
+			}
+			return nil
+		})
+	}
+	if c.metricGroups.Routes {
+		g.Go(func() error {
+			var err error
+			if routes, err = c.nginxClient.GetRoutesWithContext(gctx); err != nil {
+				warn("Error getting routes", err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
 		c.upMetric.Set(nginxDown)
 		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
 		level.Error(c.logger).Log("msg", "Error getting stats", "error", err.Error())
 		return
 	}
 
 	c.upMetric.Set(nginxUp)
 	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
+	c.detectAPI(stats)
+
+	if c.metricGroups.Connections {
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections_accepted"],
+			prometheus.CounterValue, float64(stats.Connections.Accepted))
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections_active"],
+			prometheus.GaugeValue, float64(stats.Connections.Active))
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections_idle"],
+			prometheus.GaugeValue, float64(stats.Connections.Idle))
+		ch <- prometheus.MustNewConstMetric(c.metrics["connections_closed"],
+			prometheus.CounterValue, float64(stats.Connections.Closed))
+	}
+	if c.metricGroups.Requests {
+		ch <- prometheus.MustNewConstMetric(c.metrics["http_requests_total"],
+			prometheus.CounterValue, float64(stats.Requests.Total))
+	}
+	if stats.Version != "" {
+		ch <- prometheus.MustNewConstMetric(c.metrics["build_info"],
+			prometheus.GaugeValue, 1, stats.Version)
+	}
+	for language, modules := range stats.Modules {
+		for _, module := range modules {
+			ch <- prometheus.MustNewConstMetric(c.metrics["module_info"],
+				prometheus.GaugeValue, 1, language, module.Version)
+		}
+	}
+
+	if config != nil {
+		c.collectConfig(ch, config)
+	}
+
+	for name, bundle := range certificates {
+		expiry, err := bundle.Expiry()
+		if err != nil {
+			level.Warn(c.logger).Log("msg", "Error getting certificate expiry", "name", name, "error", err.Error())
+			continue
+		}
+		ch <- prometheus.MustNewConstMetric(c.metrics["certificate_expiry"],
+			prometheus.GaugeValue, float64(expiry.Unix()), name)
+	}
+
+	for name, upstream := range upstreams {
+		ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["servers"],
+			prometheus.GaugeValue, float64(len(upstream.Servers)), name)
+		for address, server := range upstream.Servers {
+			ch <- prometheus.MustNewConstMetric(c.upstreamMetrics["weight"],
+				prometheus.GaugeValue, server.EffectiveWeight(), name, address)
+		}
+	}
+
+	if listeners != nil {
+		ch <- prometheus.MustNewConstMetric(c.metrics["listeners_total"],
+			prometheus.GaugeValue, float64(len(listeners)))
+	}
+	applicationListeners := make(map[string]int)
+	for address, listener := range listeners {
+		targetType, target := listener.Target()
+		if targetType == "applications" {
+			applicationListeners[target]++
+		}
+		ch <- prometheus.MustNewConstMetric(c.listenerMetrics["info"],
+			prometheus.GaugeValue, 1, address, listener.Pass, targetType, target)
+	}
+
+	applicationRoutes := make(map[string]int)
+	if routes != nil {
+		ch <- prometheus.MustNewConstMetric(c.metrics["routes_total"],
+			prometheus.GaugeValue, float64(len(routes)))
+	}
+	for name, steps := range routes {
+		missingApplications := 0
+		for _, step := range steps {
+			targetType, target := step.Target()
+			if targetType != "applications" {
+				continue
+			}
+			applicationRoutes[target]++
+			// targets with variables are resolved per request and cannot be checked
+			if _, ok := applications[target]; !ok && !strings.Contains(target, "$") {
+				missingApplications++
+			}
+		}
+		ch <- prometheus.MustNewConstMetric(c.routeMetrics["steps"],
+			prometheus.GaugeValue, float64(len(steps)), name)
+		if applications != nil {
+			ch <- prometheus.MustNewConstMetric(c.routeMetrics["missing_applications"],
+				prometheus.GaugeValue, float64(missingApplications), name)
+		}
+	}
+
+	if !c.metricGroups.Applications {
+		return
+	}
+
+	// the status of applications that fail to start is missing, so the configuration is preferred
+	applicationsTotal := len(stats.Applications)
+	if applications != nil {
+		applicationsTotal = len(applications)
+	}
+	ch <- prometheus.MustNewConstMetric(c.metrics["applications_total"],
+		prometheus.GaugeValue, float64(applicationsTotal))
 
-	ch <- prometheus.MustNewConstMetric(c.metrics["connections_accepted"],
-		prometheus.CounterValue, float64(stats.Connections.Accepted))
-	ch <- prometheus.MustNewConstMetric(c.metrics["connections_active"],
-		prometheus.GaugeValue, float64(stats.Connections.Active))
-	ch <- prometheus.MustNewConstMetric(c.metrics["connections_idle"],
-		prometheus.GaugeValue, float64(stats.Connections.Idle))
-	ch <- prometheus.MustNewConstMetric(c.metrics["connections_closed"],
-		prometheus.CounterValue, float64(stats.Connections.Closed))
-	ch <- prometheus.MustNewConstMetric(c.metrics["http_requests_total"],
-		prometheus.CounterValue, float64(stats.Requests.Total))
 	for s, application := range stats.Applications {
+		config, configured := applications[s]
+		labelValues := []string{s, config.Language(), config.User, config.Group}
+
 		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes_running"],
-			prometheus.GaugeValue, float64(application.Processes.Running), s)
+			prometheus.GaugeValue, float64(application.Processes.Running), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes_starting"],
-			prometheus.GaugeValue, float64(application.Processes.Starting), s)
+			prometheus.GaugeValue, float64(application.Processes.Starting), labelValues...)
 		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes_idle"],
-			prometheus.GaugeValue, float64(application.Processes.Idle), s)
+			prometheus.GaugeValue, float64(application.Processes.Idle), labelValues...)
+		if configured {
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["isolation_info"],
+				prometheus.GaugeValue, 1, s, strings.Join(config.Isolation.EnabledNamespaces(), ","), config.Isolation.Rootfs, config.Isolation.Cgroup.Path)
+			processesMax, processesSpare := config.ProcessLimits()
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes_max"],
+				prometheus.GaugeValue, float64(processesMax), labelValues...)
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes_spare"],
+				prometheus.GaugeValue, float64(processesSpare), labelValues...)
+		}
 		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["requests_active"],
-			prometheus.GaugeValue, float64(application.Requests.Active), s)
+			prometheus.GaugeValue, float64(application.Requests.Active), labelValues...)
+		if c.applicationRequestsTotal {
+			var requestsTotal int64
+			if application.Requests.Total != nil {
+				requestsTotal = *application.Requests.Total
+			}
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["requests_total"],
+				prometheus.CounterValue, float64(requestsTotal), labelValues...)
+		}
+		if listeners != nil {
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["listeners"],
+				prometheus.GaugeValue, float64(applicationListeners[s]), labelValues...)
+		}
+		if routes != nil {
+			ch <- prometheus.MustNewConstMetric(c.applicationMetrics["routes"],
+				prometheus.GaugeValue, float64(applicationRoutes[s]), labelValues...)
+		}
+	}
+}
+
+// collectConfig sends the hash of the configuration and the time it last changed. The first configuration
+// seen after the start of the exporter counts as a change.
+func (c *NginxUnitCollector) collectConfig(ch chan<- prometheus.Metric, config map[string]interface{}) {
+	// maps are marshaled with sorted keys, so the hash does not depend on the order of the objects in the response
+	data, err := json.Marshal(config)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "Error encoding config", "error", err.Error())
+		return
 	}
+	h := fnv.New32a()
+	h.Write(data)
+	hash := h.Sum32()
 
+	if c.configLastChange.IsZero() || hash != c.configHash {
+		c.configHash = hash
+		c.configLastChange = time.Now()
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.metrics["config_hash"],
+		prometheus.GaugeValue, float64(c.configHash))
+	ch <- prometheus.MustNewConstMetric(c.metrics["config_last_change"],
+		prometheus.GaugeValue, float64(c.configLastChange.Unix()))
 }
 
 func newApplicationServerMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
@@ -98,3 +449,13 @@ func newApplicationServerMetric(namespace string, metricName string, docString s
 	labels = append(labels, variableLabelNames...)
 	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "applications", metricName), docString, labels, constLabels)
 }
+
+func newListenerMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := []string{"listener"}
+	labels = append(labels, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "listener", metricName), docString, labels, constLabels)
+}
+
+func newRouteMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "route", metricName), docString, []string{"route"}, constLabels)
+}
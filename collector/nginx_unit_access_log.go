@@ -0,0 +1,210 @@
+package collector
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/tail"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unitAccessLogLine matches the default NGINX Unit access log format followed by $request_time and an optional
+// quoted application name, e.g.
+// 127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "blogs".
+var unitAccessLogLine = regexp.MustCompile(`^\S+ \S+ \S+ \[[^\]]*\] "(?:[^"\\]|\\.)*" (\d{3}) \S+ "(?:[^"\\]|\\.)*" "(?:[^"\\]|\\.)*" (\d+(?:\.\d+)?)(?: "([^"]*)")?`)
+
+// NginxUnitAccessLogCollector collects request metrics from the lines of the NGINX Unit access log.
+// It implements prometheus.Collector interface.
+type NginxUnitAccessLogCollector struct {
+	requestDuration *prometheus.HistogramVec
+	responses       *prometheus.CounterVec
+	websockets      *prometheus.CounterVec
+	unparsedLines   prometheus.Counter
+	// lastSeen is the time of the last line of every application, the series of the applications
+	// without lines for staleAfter are removed
+	lastSeen   map[string]time.Time
+	staleAfter time.Duration
+	now        func() time.Time
+	mutex      sync.Mutex
+	logger     log.Logger
+}
+
+type unitAccessLogEntry struct {
+	status      string
+	duration    float64
+	application string
+}
+
+// NewNginxUnitAccessLogCollector creates an NginxUnitAccessLogCollector. The metrics of an application are removed
+// when the access log has no lines of it for staleAfter, e.g. after the application is deleted; zero keeps them forever.
+// With nativeHistograms, the request duration is a native histogram instead of a histogram with classic buckets.
+func NewNginxUnitAccessLogCollector(namespace string, staleAfter time.Duration, nativeHistograms bool, constLabels map[string]string, logger log.Logger) *NginxUnitAccessLogCollector {
+	return &NginxUnitAccessLogCollector{
+		logger:     logger,
+		lastSeen:   make(map[string]time.Time),
+		staleAfter: staleAfter,
+		now:        time.Now,
+		requestDuration: newLogHistogramVec(prometheus.HistogramOpts{
+			Namespace:   namespace,
+			Name:        "http_request_duration_seconds",
+			Help:        "Duration of the requests logged in the access log",
+			Buckets:     prometheus.DefBuckets,
+			ConstLabels: constLabels,
+		}, []string{"application"}, nativeHistograms),
+		responses: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "http_responses_total",
+			Help:        "Total responses logged in the access log",
+			ConstLabels: constLabels,
+		}, []string{"application", "status"}),
+		websockets: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "websocket_connections_total",
+			Help:        "Total closed WebSocket connections logged in the access log",
+			ConstLabels: constLabels,
+		}, []string{"application"}),
+		unparsedLines: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace:   namespace,
+			Name:        "access_log_unparsed_lines_total",
+			Help:        "Total access log lines that could not be parsed",
+			ConstLabels: constLabels,
+		}),
+	}
+}
+
+// HandleLine updates the metrics with a line of the access log.
+func (c *NginxUnitAccessLogCollector) HandleLine(line string) {
+	entry, ok := parseUnitAccessLogLine(line)
+	if !ok {
+		c.unparsedLines.Inc()
+		level.Debug(c.logger).Log("msg", "Error parsing access log line", "line", line)
+		return
+	}
+	c.mutex.Lock()
+	c.lastSeen[entry.application] = c.now()
+	c.mutex.Unlock()
+
+	c.responses.WithLabelValues(entry.application, entry.status).Inc()
+	// WebSocket connections are logged with the 101 status when they are closed, their duration is not a request duration
+	if entry.status == "101" {
+		c.websockets.WithLabelValues(entry.application).Inc()
+		return
+	}
+	c.requestDuration.WithLabelValues(entry.application).Observe(entry.duration)
+}
+
+// Describe sends the super-set of all possible descriptors of NGINX Unit access log metrics
+// to the provided channel.
+func (c *NginxUnitAccessLogCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.requestDuration.Describe(ch)
+	c.responses.Describe(ch)
+	c.websockets.Describe(ch)
+	c.unparsedLines.Describe(ch)
+}
+
+// Collect sends the metrics of the access log lines handled so far to the provided channel.
+func (c *NginxUnitAccessLogCollector) Collect(ch chan<- prometheus.Metric) {
+	c.removeStaleApplications()
+
+	c.requestDuration.Collect(ch)
+	c.responses.Collect(ch)
+	c.websockets.Collect(ch)
+	c.unparsedLines.Collect(ch)
+}
+
+func (c *NginxUnitAccessLogCollector) removeStaleApplications() {
+	if c.staleAfter <= 0 {
+		return
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for application, lastSeen := range c.lastSeen {
+		if c.now().Sub(lastSeen) < c.staleAfter {
+			continue
+		}
+		labels := prometheus.Labels{"application": application}
+		c.requestDuration.DeletePartialMatch(labels)
+		c.responses.DeletePartialMatch(labels)
+		c.websockets.DeletePartialMatch(labels)
+		delete(c.lastSeen, application)
+	}
+}
+
+func parseUnitAccessLogLine(line string) (unitAccessLogEntry, bool) {
+	matches := unitAccessLogLine.FindStringSubmatch(line)
+	if matches == nil {
+		return unitAccessLogEntry{}, false
+	}
+	duration, err := strconv.ParseFloat(matches[2], 64)
+	if err != nil {
+		return unitAccessLogEntry{}, false
+	}
+	return unitAccessLogEntry{
+		status:      matches[1],
+		duration:    duration,
+		application: matches[3],
+	}, true
+}
+
+// nginxUnitAccessLog is the access log collector and the tailer of the log shared by the factories.
+type nginxUnitAccessLog struct {
+	collector *NginxUnitAccessLogCollector
+	tailer    *tail.Tailer
+}
+
+// newNginxUnitAccessLogFactories registers the flags of the NGINX Unit access log. The access log collector and the
+// collector of the log file share the tailer of the log.
+func newNginxUnitAccessLogFactories() []Factory {
+	var path *string
+	var staleAfter *time.Duration
+	var accessLog shared[nginxUnitAccessLog]
+	newAccessLog := func(env Environment) (nginxUnitAccessLog, error) {
+		if *path == "" || !env.Unit {
+			return nginxUnitAccessLog{}, nil
+		}
+		if *staleAfter < 0 {
+			return nginxUnitAccessLog{}, fmt.Errorf("the stale duration %v of the NGINX Unit access log must not be negative", *staleAfter)
+		}
+		return accessLog.get(func() (nginxUnitAccessLog, error) {
+			c := NewNginxUnitAccessLogCollector(env.UnitNamespace, *staleAfter, env.NativeHistograms, env.ConstLabels, env.Logger)
+			tailer := tail.NewTailer(*path, time.Second, env.Logger)
+			go tailer.Run(env.Context, c.HandleLine)
+			return nginxUnitAccessLog{collector: c, tailer: tailer}, nil
+		})
+	}
+	return []Factory{
+		{
+			Name: "unit_access_log",
+			Flags: func(app *kingpin.Application) {
+				path = app.Flag("unit.access-log", "Path to the NGINX Unit access log to export request duration and response metrics from. The log format must end with $request_time and an optional quoted application name.").Default("").Envar("UNIT_ACCESS_LOG").String()
+				staleAfter = app.Flag("unit.access-log-stale-after", "Remove the NGINX Unit access log metrics of an application after no lines of it were logged for this duration. 0 keeps the metrics forever.").Default("1h").Envar("UNIT_ACCESS_LOG_STALE_AFTER").Duration()
+			},
+			New: func(env Environment) (prometheus.Collector, error) {
+				accessLog, err := newAccessLog(env)
+				if err != nil || accessLog.collector == nil {
+					return nil, err
+				}
+				return accessLog.collector, nil
+			},
+		},
+		{
+			Name:  "unit_access_log_file",
+			Flags: func(app *kingpin.Application) {},
+			New: func(env Environment) (prometheus.Collector, error) {
+				accessLog, err := newAccessLog(env)
+				if err != nil || accessLog.tailer == nil {
+					return nil, err
+				}
+				return NewLogFileCollector(accessLog.tailer, env.UnitNamespace, env.ConstLabels), nil
+			},
+		},
+	}
+}
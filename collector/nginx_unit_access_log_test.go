@@ -0,0 +1,96 @@
+package collector
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseUnitAccessLogLine(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		line   string
+		want   unitAccessLogEntry
+		wantOk bool
+	}{
+		{
+			name:   "line with application",
+			line:   `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "blogs"`,
+			want:   unitAccessLogEntry{status: "200", duration: 0.012, application: "blogs"},
+			wantOk: true,
+		},
+		{
+			name:   "line without application",
+			line:   `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "POST /login HTTP/1.1" 502 0 "https://example.com/" "Mozilla/5.0" 1.5`,
+			want:   unitAccessLogEntry{status: "502", duration: 1.5},
+			wantOk: true,
+		},
+		{
+			name:   "escaped quotes in user agent",
+			line:   `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 404 10 "-" "agent \"quoted\"" 0 "blogs"`,
+			want:   unitAccessLogEntry{status: "404", duration: 0, application: "blogs"},
+			wantOk: true,
+		},
+		{
+			name:   "default format without request time",
+			line:   `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2"`,
+			wantOk: false,
+		},
+		{
+			name:   "garbage",
+			line:   "not an access log line",
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseUnitAccessLogLine(tt.line)
+			if ok != tt.wantOk {
+				t.Fatalf("parseUnitAccessLogLine() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if got != tt.want {
+				t.Errorf("parseUnitAccessLogLine() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNginxUnitAccessLogCollectorWebSocket(t *testing.T) {
+	t.Parallel()
+
+	collector := NewNginxUnitAccessLogCollector("nginxunit", 0, false, nil, log.NewNopLogger())
+	collector.HandleLine(`127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET /ws HTTP/1.1" 101 0 "-" "node" 300.5 "chat"`)
+	collector.HandleLine(`127.0.0.1 - - [21/Oct/2015:16:29:42 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "chat"`)
+
+	if got := testutil.ToFloat64(collector.websockets.WithLabelValues("chat")); got != 1 {
+		t.Errorf("websocket connections = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(collector.responses.WithLabelValues("chat", "101")); got != 1 {
+		t.Errorf("101 responses = %v, want 1", got)
+	}
+
+	const want = `# HELP nginxunit_http_request_duration_seconds Duration of the requests logged in the access log
+# TYPE nginxunit_http_request_duration_seconds histogram
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.005"} 0
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.01"} 0
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.025"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.05"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.1"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.25"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="0.5"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="1"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="2.5"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="5"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="10"} 1
+nginxunit_http_request_duration_seconds_bucket{application="chat",le="+Inf"} 1
+nginxunit_http_request_duration_seconds_sum{application="chat"} 0.012
+nginxunit_http_request_duration_seconds_count{application="chat"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(want), "nginxunit_http_request_duration_seconds"); err != nil {
+		t.Error(err)
+	}
+}
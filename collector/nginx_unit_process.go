@@ -0,0 +1,183 @@
+package collector
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/procfs"
+)
+
+// unitApplicationProcessTitle matches the process title NGINX Unit sets for application processes, e.g. unit: "blogs" application.
+var unitApplicationProcessTitle = regexp.MustCompile(`^unit: "([^"]+)" application`)
+
+// NginxUnitProcessCollector collects resource usage of NGINX Unit application processes from procfs.
+// It implements prometheus.Collector interface.
+type NginxUnitProcessCollector struct {
+	fs                 procfs.FS
+	applicationMetrics map[string]*prometheus.Desc
+	// processes are the application processes found by the previous collect, nil before the first one
+	processes map[processID]string
+	starts    map[string]float64
+	cpuTimes  *processCPUTimes
+	mutex     sync.Mutex
+	logger    log.Logger
+}
+
+type unitApplicationProcesses struct {
+	count         int
+	residentBytes float64
+	openFDs       float64
+}
+
+// NewNginxUnitProcessCollector creates an NginxUnitProcessCollector which reads processes from the procfs mounted at procfsPath.
+func NewNginxUnitProcessCollector(procfsPath string, namespace string, constLabels map[string]string, logger log.Logger) (*NginxUnitProcessCollector, error) {
+	fs, err := procfs.NewFS(procfsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &NginxUnitProcessCollector{
+		fs:       fs,
+		logger:   logger,
+		starts:   make(map[string]float64),
+		cpuTimes: newProcessCPUTimes(),
+		applicationMetrics: map[string]*prometheus.Desc{
+			"processes":                     newApplicationServerMetric(namespace, "processes", "Application processes found in procfs", []string{}, constLabels),
+			"process_resident_memory_bytes": newApplicationServerMetric(namespace, "process_resident_memory_bytes", "Resident memory size of the application processes in bytes", []string{}, constLabels),
+			"process_cpu_seconds_total":     newApplicationServerMetric(namespace, "process_cpu_seconds_total", "Total user and system CPU time spent by the application processes in seconds", []string{}, constLabels),
+			"process_open_fds":              newApplicationServerMetric(namespace, "process_open_fds", "Open file descriptors of the application processes", []string{}, constLabels),
+			"process_restarts_total":        newApplicationServerMetric(namespace, "process_restarts_total", "Application processes started since the exporter start, by restarts or by scaling", []string{}, constLabels),
+		},
+	}, nil
+}
+
+// Describe sends the super-set of all possible descriptors of NGINX Unit process metrics
+// to the provided channel.
+func (c *NginxUnitProcessCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.applicationMetrics {
+		ch <- m
+	}
+}
+
+// Collect reads the NGINX Unit application processes from procfs and sends the metrics to the provided channel.
+func (c *NginxUnitProcessCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	procs, err := c.fs.AllProcs()
+	if err != nil {
+		level.Error(c.logger).Log("msg", "Error reading processes", "error", err.Error())
+		return
+	}
+
+	applications := make(map[string]*unitApplicationProcesses)
+	processes := make(map[processID]string)
+	for _, p := range procs {
+		// processes may exit while being read, errors are expected and skipped
+		cmdline, err := p.CmdLine()
+		if err != nil {
+			continue
+		}
+		name, ok := parseUnitApplicationName(cmdline)
+		if !ok {
+			continue
+		}
+		stat, err := p.Stat()
+		if err != nil {
+			continue
+		}
+
+		application, ok := applications[name]
+		if !ok {
+			application = &unitApplicationProcesses{}
+			applications[name] = application
+		}
+		application.count++
+		processes[processID{pid: p.PID, startTime: stat.Starttime}] = name
+		application.residentBytes += float64(stat.ResidentMemory())
+		c.cpuTimes.observe(p.PID, stat.Starttime, name, stat.CPUTime())
+		if fds, err := p.FileDescriptorsLen(); err == nil {
+			application.openFDs += float64(fds)
+		}
+	}
+
+	if c.processes != nil {
+		for name, started := range countStartedProcesses(c.processes, processes) {
+			c.starts[name] += float64(started)
+		}
+	}
+	c.processes = processes
+	// the applications without processes are removed or stopped, their restarts start from zero again
+	for name := range c.starts {
+		if _, ok := applications[name]; !ok {
+			delete(c.starts, name)
+		}
+	}
+	// the CPU time of the exited processes is kept, also while the application has no processes, e.g. when it is
+	// scaled down to zero
+	for name, seconds := range c.cpuTimes.totals() {
+		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["process_cpu_seconds_total"],
+			prometheus.CounterValue, seconds, name)
+	}
+
+	for name, application := range applications {
+		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["process_restarts_total"],
+			prometheus.CounterValue, c.starts[name], name)
+		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["processes"],
+			prometheus.GaugeValue, float64(application.count), name)
+		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["process_resident_memory_bytes"],
+			prometheus.GaugeValue, application.residentBytes, name)
+		ch <- prometheus.MustNewConstMetric(c.applicationMetrics["process_open_fds"],
+			prometheus.GaugeValue, application.openFDs, name)
+	}
+}
+
+// countStartedProcesses returns the number of processes of each application that are not in the previous processes.
+func countStartedProcesses(previous, current map[processID]string) map[string]int {
+	started := make(map[string]int)
+	for process, name := range current {
+		if _, ok := previous[process]; !ok {
+			started[name]++
+		}
+	}
+	return started
+}
+
+func parseUnitApplicationName(cmdline []string) (string, bool) {
+	if len(cmdline) == 0 {
+		return "", false
+	}
+	matches := unitApplicationProcessTitle.FindStringSubmatch(strings.Join(cmdline, " "))
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// newNginxUnitProcessFactory registers the flags of the NGINX Unit application process metrics.
+func newNginxUnitProcessFactory() Factory {
+	var enabled *bool
+	var procfsPath *string
+	return Factory{
+		Name: "unit_process",
+		Flags: func(app *kingpin.Application) {
+			enabled = app.Flag("unit.process-metrics", "Export resource usage of NGINX Unit application processes read from procfs. The exporter must run on the same host as NGINX Unit.").Default("false").Envar("UNIT_PROCESS_METRICS").Bool()
+			procfsPath = app.Flag("unit.procfs-path", "Path to the procfs mount point used for the NGINX Unit process metrics.").Default("/proc").Envar("UNIT_PROCFS_PATH").String()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if !*enabled || !env.Unit {
+				return nil, nil
+			}
+			c, err := NewNginxUnitProcessCollector(*procfsPath, env.UnitNamespace, env.ConstLabels, env.Logger)
+			if err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
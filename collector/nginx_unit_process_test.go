@@ -0,0 +1,99 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseUnitApplicationName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cmdline  []string
+		wantName string
+		wantOk   bool
+	}{
+		{
+			name:     "application process",
+			cmdline:  []string{`unit: "blogs" application`},
+			wantName: "blogs",
+			wantOk:   true,
+		},
+		{
+			name:     "application process with padded title",
+			cmdline:  []string{`unit: "shop-api" application`, "", ""},
+			wantName: "shop-api",
+			wantOk:   true,
+		},
+		{
+			name:    "prototype process",
+			cmdline: []string{`unit: "blogs" prototype`},
+			wantOk:  false,
+		},
+		{
+			name:    "router process",
+			cmdline: []string{"unit: router"},
+			wantOk:  false,
+		},
+		{
+			name:    "kernel thread",
+			cmdline: []string{},
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, ok := parseUnitApplicationName(tt.cmdline)
+			if ok != tt.wantOk {
+				t.Errorf("parseUnitApplicationName() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if name != tt.wantName {
+				t.Errorf("parseUnitApplicationName() = %v, want %v", name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestCountStartedProcesses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		previous map[processID]string
+		current  map[processID]string
+		want     map[string]int
+	}{
+		{
+			name:     "same processes",
+			previous: map[processID]string{{pid: 10, startTime: 100}: "blogs"},
+			current:  map[processID]string{{pid: 10, startTime: 100}: "blogs"},
+			want:     map[string]int{},
+		},
+		{
+			name:     "restarted processes",
+			previous: map[processID]string{{pid: 10, startTime: 100}: "blogs", {pid: 11, startTime: 100}: "shop"},
+			current:  map[processID]string{{pid: 20, startTime: 200}: "blogs", {pid: 21, startTime: 200}: "blogs", {pid: 11, startTime: 100}: "shop"},
+			want:     map[string]int{"blogs": 2},
+		},
+		{
+			name:     "reused pid",
+			previous: map[processID]string{{pid: 10, startTime: 100}: "blogs"},
+			current:  map[processID]string{{pid: 10, startTime: 300}: "blogs"},
+			want:     map[string]int{"blogs": 1},
+		},
+		{
+			name:     "stopped processes",
+			previous: map[processID]string{{pid: 10, startTime: 100}: "blogs"},
+			current:  map[processID]string{},
+			want:     map[string]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := countStartedProcesses(tt.previous, tt.current); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("countStartedProcesses() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"testing"
+	"time"
+
+	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/unit/unittest"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNginxUnitCollectorRemovedApplications(t *testing.T) {
+	t.Parallel()
+
+	server := unittest.NewServer()
+	defer server.Close()
+
+	server.SetStatus(unittest.NewStatus().WithApplication("blogs", 1, 0, 1, 0, 10).WithApplication("shop", 2, 0, 0, 1, 20))
+	server.SetConfig("applications/blogs", map[string]interface{}{"type": "php"})
+	server.SetConfig("applications/shop", map[string]interface{}{"type": "python"})
+
+	client, err := unitclient.NewNginxClient(server.Client(), server.StatusURL())
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	metricGroups := NewUnitMetricGroups(true, true, true, true, true, true, true, true)
+	collector := NewNginxUnitCollector(client, "nginxunit", metricGroups, time.Second, nil, log.NewNopLogger())
+
+	if got := testutil.CollectAndCount(collector, "nginxunit_applications_processes_running"); got != 2 {
+		t.Errorf("processes_running series = %v, want 2", got)
+	}
+
+	server.SetStatus(unittest.NewStatus().WithApplication("blogs", 1, 0, 1, 0, 12))
+	server.DeleteConfig("applications/shop")
+
+	if got := testutil.CollectAndCount(collector, "nginxunit_applications_processes_running"); got != 1 {
+		t.Errorf("processes_running series after the application is removed = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(collector, "nginxunit_applications_total"); got != 1 {
+		t.Errorf("applications_total series = %v, want 1", got)
+	}
+}
+
+func TestNginxUnitCollectorDetectsAPI(t *testing.T) {
+	t.Parallel()
+
+	server := unittest.NewServer()
+	defer server.Close()
+
+	status := unittest.NewStatus().WithApplication("blogs", 1, 0, 1, 0, 0)
+	status.Applications["blogs"].Requests.Total = nil
+	server.SetStatus(status)
+
+	client, err := unitclient.NewNginxClient(server.Client(), server.StatusURL())
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+	metricGroups := NewUnitMetricGroups(true, true, true, true, true, true, true, true)
+	collector := NewNginxUnitCollector(client, "nginxunit", metricGroups, time.Second, nil, log.NewNopLogger())
+
+	server.SetStatus(unittest.NewStatus().WithApplication("blogs", 1, 0, 1, 0, 10))
+
+	if got := testutil.CollectAndCount(collector, "nginxunit_applications_requests_total"); got != 0 {
+		t.Errorf("requests_total series = %v, want 0 while the exporter runs", got)
+	}
+	if got := testutil.CollectAndCount(collector, "nginxunit_applications_processes_running"); got != 1 {
+		t.Errorf("processes_running series = %v, want 1", got)
+	}
+}
+
+func TestNginxUnitAccessLogCollectorStaleApplications(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2023, time.October, 1, 12, 0, 0, 0, time.UTC)
+	collector := NewNginxUnitAccessLogCollector("nginxunit", time.Hour, false, nil, log.NewNopLogger())
+	collector.now = func() time.Time { return now }
+
+	collector.HandleLine(`127.0.0.1 - - [01/Oct/2023:12:00:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "blogs"`)
+	collector.HandleLine(`127.0.0.1 - - [01/Oct/2023:12:00:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "shop"`)
+
+	if got := testutil.CollectAndCount(collector, "nginxunit_http_responses_total"); got != 2 {
+		t.Errorf("http_responses_total series = %v, want 2", got)
+	}
+
+	now = now.Add(45 * time.Minute)
+	collector.HandleLine(`127.0.0.1 - - [01/Oct/2023:12:45:00 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 0.012 "blogs"`)
+	now = now.Add(30 * time.Minute)
+
+	if got := testutil.CollectAndCount(collector, "nginxunit_http_responses_total"); got != 1 {
+		t.Errorf("http_responses_total series after an application is stale = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(collector, "nginxunit_http_request_duration_seconds"); got != 1 {
+		t.Errorf("http_request_duration_seconds series after an application is stale = %v, want 1", got)
+	}
+}
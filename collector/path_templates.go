@@ -0,0 +1,68 @@
+package collector
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// otherPath is the template of the paths that match no template, so that the paths do not create new series.
+const otherPath = "other"
+
+// PathTemplates normalize the request paths logged in the access log into a bounded set of templates.
+type PathTemplates struct {
+	// Templates are slash separated segments, e.g. /api/users/{id}/orders. A segment in braces matches any segment,
+	// a * as the last segment matches the rest of the path. The first matching template is used.
+	Templates []string `yaml:"templates"`
+
+	segments [][]string
+}
+
+// LoadPathTemplates reads PathTemplates from a YAML file.
+func LoadPathTemplates(path string) (*PathTemplates, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the path templates: %w", err)
+	}
+	var templates PathTemplates
+	if err := yaml.UnmarshalStrict(data, &templates); err != nil {
+		return nil, fmt.Errorf("failed to parse the path templates %v: %w", path, err)
+	}
+	for _, template := range templates.Templates {
+		if !strings.HasPrefix(template, "/") {
+			return nil, fmt.Errorf("invalid path template %q: the template must start with /", template)
+		}
+		templates.segments = append(templates.segments, strings.Split(template, "/"))
+	}
+	return &templates, nil
+}
+
+// Match returns the first template that matches the request path, or other. The query string is ignored.
+func (t *PathTemplates) Match(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(path, "/")
+	for i, template := range t.segments {
+		if matchPathSegments(template, segments) {
+			return t.Templates[i]
+		}
+	}
+	return otherPath
+}
+
+func matchPathSegments(template []string, segments []string) bool {
+	for i, segment := range template {
+		if segment == "*" && i == len(template)-1 {
+			return len(segments) >= len(template)
+		}
+		if i >= len(segments) {
+			return false
+		}
+		isParameter := strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}")
+		if !isParameter && segment != segments[i] {
+			return false
+		}
+	}
+	return len(segments) == len(template)
+}
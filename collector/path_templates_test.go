@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func loadTestPathTemplates(t *testing.T, data string) (*PathTemplates, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "templates.yml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return LoadPathTemplates(path)
+}
+
+func TestPathTemplatesMatch(t *testing.T) {
+	t.Parallel()
+
+	templates, err := loadTestPathTemplates(t, "templates:\n  - /api/users/{id}\n  - /api/users/{id}/orders\n  - /static/*\n  - /\n")
+	if err != nil {
+		t.Fatalf("LoadPathTemplates() error = %v", err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/api/users/42", want: "/api/users/{id}"},
+		{path: "/api/users/42/orders?page=2", want: "/api/users/{id}/orders"},
+		{path: "/api/users", want: "other"},
+		{path: "/static/css/main.css", want: "/static/*"},
+		{path: "/static", want: "other"},
+		{path: "/", want: "/"},
+		{path: "", want: "other"},
+	}
+	for _, tt := range tests {
+		if got := templates.Match(tt.path); got != tt.want {
+			t.Errorf("Match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLoadPathTemplatesInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := loadTestPathTemplates(t, "templates:\n  - api/users\n"); err == nil {
+		t.Error("LoadPathTemplates() of a relative template error = nil, want an error")
+	}
+	if _, err := loadTestPathTemplates(t, "paths:\n  - /api\n"); err == nil {
+		t.Error("LoadPathTemplates() of an unknown key error = nil, want an error")
+	}
+}
+
+func TestNginxAccessLogCollectorPathTemplates(t *testing.T) {
+	t.Parallel()
+
+	templates, err := loadTestPathTemplates(t, "templates:\n  - /api/users/{id}\n")
+	if err != nil {
+		t.Fatalf("LoadPathTemplates() error = %v", err)
+	}
+	format, err := logformat.New(`$host "$request" $status $bytes_sent $request_length`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.SetPathTemplates(templates)
+	collector.HandleLine(`example.com "GET /api/users/1 HTTP/1.1" 200 612 80`)
+	collector.HandleLine(`example.com "GET /api/users/2?full=1 HTTP/1.1" 200 388 90`)
+	collector.HandleLine(`example.com "GET /favicon.ico HTTP/1.1" 404 150 70`)
+
+	expected := `# HELP nginx_http_path_requests_total Total requests logged in the access log by the path template
+# TYPE nginx_http_path_requests_total counter
+nginx_http_path_requests_total{path="/api/users/{id}",vhost="example.com"} 2
+nginx_http_path_requests_total{path="other",vhost="example.com"} 1
+# HELP nginx_http_path_sent_bytes_total Total bytes of the responses logged in the access log by the path template
+# TYPE nginx_http_path_sent_bytes_total counter
+nginx_http_path_sent_bytes_total{path="/api/users/{id}",vhost="example.com"} 1000
+nginx_http_path_sent_bytes_total{path="other",vhost="example.com"} 150
+# HELP nginx_http_sent_bytes_total Total bytes of the responses logged in the access log
+# TYPE nginx_http_sent_bytes_total counter
+nginx_http_sent_bytes_total{vhost="example.com"} 1150
+# HELP nginx_http_received_bytes_total Total bytes of the requests logged in the access log
+# TYPE nginx_http_received_bytes_total counter
+nginx_http_received_bytes_total{vhost="example.com"} 240
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_path_requests_total", "nginx_http_path_sent_bytes_total", "nginx_http_sent_bytes_total", "nginx_http_received_bytes_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,54 @@
+package collector
+
+// processCPUTimes sums the CPU time of groups of processes, e.g. the nginx workers, across collects. The CPU time of
+// the processes that exited since the previous collect is kept in the sum, so that the total of a group does not go
+// down when nginx is reloaded or restarted.
+type processCPUTimes struct {
+	exited map[string]float64
+	// processes are the processes of the previous collect, current the ones observed for the next totals
+	processes map[processID]processCPUTime
+	current   map[processID]processCPUTime
+}
+
+// processID identifies a process, the start time tells apart processes with a reused pid.
+type processID struct {
+	pid       int
+	startTime uint64
+}
+
+type processCPUTime struct {
+	group   string
+	seconds float64
+}
+
+func newProcessCPUTimes() *processCPUTimes {
+	return &processCPUTimes{
+		exited:  make(map[string]float64),
+		current: make(map[processID]processCPUTime),
+	}
+}
+
+// observe records the CPU time of a process of the group for the next totals.
+func (t *processCPUTimes) observe(pid int, startTime uint64, group string, seconds float64) {
+	t.current[processID{pid: pid, startTime: startTime}] = processCPUTime{group: group, seconds: seconds}
+}
+
+// totals returns the total CPU time of each group: the observed processes and the processes that exited before.
+func (t *processCPUTimes) totals() map[string]float64 {
+	for id, previous := range t.processes {
+		if _, ok := t.current[id]; !ok {
+			t.exited[previous.group] += previous.seconds
+		}
+	}
+	t.processes = t.current
+	t.current = make(map[processID]processCPUTime)
+
+	totals := make(map[string]float64)
+	for group, seconds := range t.exited {
+		totals[group] = seconds
+	}
+	for _, process := range t.processes {
+		totals[process.group] += process.seconds
+	}
+	return totals
+}
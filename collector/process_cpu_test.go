@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProcessCPUTimes(t *testing.T) {
+	t.Parallel()
+
+	type observation struct {
+		pid       int
+		startTime uint64
+		group     string
+		seconds   float64
+	}
+	tests := []struct {
+		name     string
+		collects [][]observation
+		want     map[string]float64
+	}{
+		{
+			name: "same processes",
+			collects: [][]observation{
+				{{1, 10, "master", 1}, {2, 11, "worker", 5}, {3, 11, "worker", 7}},
+				{{1, 10, "master", 1.5}, {2, 11, "worker", 6}, {3, 11, "worker", 9}},
+			},
+			want: map[string]float64{"master": 1.5, "worker": 15},
+		},
+		{
+			name: "reload replaces the workers",
+			collects: [][]observation{
+				{{1, 10, "master", 1}, {2, 11, "worker", 5}, {3, 11, "worker", 7}},
+				{{1, 10, "master", 1.5}, {4, 20, "worker", 0.5}, {5, 20, "worker", 0.25}},
+			},
+			want: map[string]float64{"master": 1.5, "worker": 12.75},
+		},
+		{
+			name: "restart with a reused pid",
+			collects: [][]observation{
+				{{1, 10, "master", 1}, {2, 11, "worker", 5}},
+				{},
+				{{1, 30, "master", 0.1}, {2, 31, "worker", 0.2}},
+			},
+			want: map[string]float64{"master": 1.1, "worker": 5.2},
+		},
+		{
+			name: "all processes of a group exited",
+			collects: [][]observation{
+				{{1, 10, "master", 1}, {2, 11, "cache loader", 3}},
+				{{1, 10, "master", 2}},
+				{{1, 10, "master", 2.5}},
+			},
+			want: map[string]float64{"master": 2.5, "cache loader": 3},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			cpuTimes := newProcessCPUTimes()
+			var got map[string]float64
+			for _, collect := range test.collects {
+				for _, o := range collect {
+					cpuTimes.observe(o.pid, o.startTime, o.group, o.seconds)
+				}
+				got = cpuTimes.totals()
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("totals() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
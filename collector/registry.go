@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Factory creates a collector that is enabled by its own flags. The exporter adds the flags of all the registered
+// factories to its command line and registers the collectors they create, so that a program that vendors this package
+// can add its own collectors with Register before the flags are parsed. Only the collectors of the scrape URIs, i.e. of
+// the stub_status page and of the NGINX Unit control API, are created by the exporter itself, because the mode of the
+// exporter, the ping and the discovery of the NGINX Unit sockets depend on them.
+type Factory struct {
+	// Name is the name of the collector in the collector label of the collector duration metric. It must be unique.
+	Name string
+	// Flags adds the flags of the collector to app. It is called once before the flags are parsed.
+	Flags func(app *kingpin.Application)
+	// New creates the collector after the flags are parsed. It returns a nil collector if the collector is not enabled
+	// by its flags.
+	New func(env Environment) (prometheus.Collector, error)
+}
+
+// Environment is passed to the New function of a Factory.
+type Environment struct {
+	// Context is done when the exporter stops, e.g. to stop the goroutines of the collector.
+	Context     context.Context
+	ConstLabels map[string]string
+	Logger      log.Logger
+	// Timeout is the timeout for scraping metrics from NGINX.
+	Timeout time.Duration
+	// Plus and Unit are set when the scrape URI is the API of NGINX Plus or of NGINX Unit.
+	Plus bool
+	Unit bool
+	// ScrapeURI is the first scrape URI of the exporter.
+	ScrapeURI string
+	// UnitNamespace is the namespace of the NGINX Unit metrics.
+	UnitNamespace string
+	// ConnectionsLimit is the maximum number of client connections of NGINX, or 0 if it is not known.
+	ConnectionsLimit uint64
+	// NativeHistograms is set to export the histograms of the access logs as native histograms.
+	NativeHistograms bool
+	// NewHTTPClient creates a client with the TLS settings, the user agent and the timeout of the exporter for a URI or
+	// a unix domain socket address. It returns the URI to request, with defaultRequestPath for an address without a path.
+	NewHTTPClient func(uri string, defaultRequestPath string) (*http.Client, string, error)
+	// Retry calls create until it succeeds, with the retries of the exporter on start to connect to NGINX.
+	Retry func(create func() (interface{}, error)) (interface{}, error)
+}
+
+// shared creates a value once for the factories that share it, e.g. a log collector and the collector of its log
+// file, whichever of them is created first.
+type shared[T any] struct {
+	once  sync.Once
+	value T
+	err   error
+}
+
+func (s *shared[T]) get(create func() (T, error)) (T, error) {
+	s.once.Do(func() {
+		s.value, s.err = create()
+	})
+	return s.value, s.err
+}
+
+var (
+	factoriesMu sync.Mutex
+	factories   = make(map[string]Factory)
+)
+
+func init() {
+	builtin := []Factory{
+		newNginxProcessFactory(),
+		newNginxConfigCheckFactory(),
+		newSSLCertificateFactory(),
+		newUpstreamCheckFactory(),
+		newStreamStsFactory(),
+		newJSONMappingFactory(),
+		newNginxUnitProcessFactory(),
+	}
+	builtin = append(builtin, newNginxPlusFactories()...)
+	builtin = append(builtin, newNginxAccessLogFactories()...)
+	builtin = append(builtin, newNginxErrorLogFactories()...)
+	builtin = append(builtin, newNginxUnitAccessLogFactories()...)
+	for _, factory := range builtin {
+		Register(factory)
+	}
+}
+
+// Register adds a Factory to the registry. It panics if the name is empty or already registered.
+func Register(factory Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	if factory.Name == "" || factory.Flags == nil || factory.New == nil {
+		panic("collector factory must have a name, flags and a constructor")
+	}
+	if _, ok := factories[factory.Name]; ok {
+		panic(fmt.Sprintf("collector factory %v is already registered", factory.Name))
+	}
+	factories[factory.Name] = factory
+}
+
+// Factories returns the registered factories sorted by their names.
+func Factories() []Factory {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+
+	registered := make([]Factory, 0, len(factories))
+	for _, factory := range factories {
+		registered = append(registered, factory)
+	}
+	sort.Slice(registered, func(i, j int) bool { return registered[i].Name < registered[j].Name })
+	return registered
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFactories(t *testing.T) {
+	t.Parallel()
+
+	app := kingpin.New("test", "")
+	factories := Factories()
+	for i, factory := range factories {
+		if i > 0 && factories[i-1].Name >= factory.Name {
+			t.Errorf("Factories() are not sorted: %v before %v", factories[i-1].Name, factory.Name)
+		}
+		factory.Flags(app)
+	}
+	if _, err := app.Parse(nil); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	env := Environment{Context: context.Background(), Logger: log.NewNopLogger()}
+	for _, factory := range factories {
+		c, err := factory.New(env)
+		if err != nil {
+			t.Errorf("New() of %v error = %v", factory.Name, err)
+		}
+		if c != nil {
+			t.Errorf("New() of %v without flags = %T, want nil", factory.Name, c)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a registered name did not panic")
+		}
+	}()
+	Register(Factory{
+		Name:  "process",
+		Flags: func(app *kingpin.Application) {},
+		New:   func(env Environment) (prometheus.Collector, error) { return nil, nil },
+	})
+}
+
+func TestShared(t *testing.T) {
+	t.Parallel()
+
+	var s shared[int]
+	calls := 0
+	create := func() (int, error) {
+		calls++
+		return calls, nil
+	}
+	for i := 0; i < 2; i++ {
+		if got, err := s.get(create); got != 1 || err != nil {
+			t.Errorf("get() = %v, %v, want 1, nil", got, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("get() called create %v times, want 1", calls)
+	}
+}
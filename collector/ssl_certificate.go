@@ -0,0 +1,157 @@
+package collector
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// certificateTarget is an address probed with a server name.
+type certificateTarget struct {
+	address    string
+	serverName string
+}
+
+// SSLCertificateCollector probes the certificates nginx serves for the server names on every scrape.
+// It implements prometheus.Collector interface.
+type SSLCertificateCollector struct {
+	targets []certificateTarget
+	timeout time.Duration
+	metrics map[string]*prometheus.Desc
+	logger  log.Logger
+}
+
+// NewSSLCertificateCollector creates an SSLCertificateCollector. A probe is given as host:port, to send the host as the
+// server name, or as host:port=name1,name2 to probe every server name at the address, e.g. 127.0.0.1:443=example.com.
+// No server name is sent for an IP address without names. A probe takes at most timeout.
+func NewSSLCertificateCollector(probes []string, timeout time.Duration, namespace string, constLabels map[string]string, logger log.Logger) (*SSLCertificateCollector, error) {
+	var targets []certificateTarget
+	for _, probe := range probes {
+		probeTargets, err := parseCertificateProbe(probe)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, probeTargets...)
+	}
+
+	return &SSLCertificateCollector{
+		targets: targets,
+		timeout: timeout,
+		logger:  logger,
+		metrics: map[string]*prometheus.Desc{
+			"expiry":  newSSLCertificateMetric(namespace, "expiry_timestamp_seconds", "Expiry time of the certificate served for the server name since unix epoch in seconds", constLabels),
+			"success": newSSLCertificateMetric(namespace, "probe_success", "Whether the certificate served for the server name could be fetched", constLabels),
+		},
+	}, nil
+}
+
+func parseCertificateProbe(probe string) ([]certificateTarget, error) {
+	address, names, hasNames := strings.Cut(probe, "=")
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate probe %q: %w", probe, err)
+	}
+	if !hasNames {
+		if net.ParseIP(host) != nil {
+			host = ""
+		}
+		return []certificateTarget{{address: address, serverName: host}}, nil
+	}
+
+	var targets []certificateTarget
+	for _, name := range strings.Split(names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			targets = append(targets, certificateTarget{address: address, serverName: name})
+		}
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("invalid certificate probe %q: no server names after =", probe)
+	}
+	return targets, nil
+}
+
+// Describe sends the super-set of all possible descriptors of the certificate metrics
+// to the provided channel.
+func (c *SSLCertificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect probes the certificates concurrently and sends the metrics to the provided channel.
+func (c *SSLCertificateCollector) Collect(ch chan<- prometheus.Metric) {
+	var wg sync.WaitGroup
+	for _, target := range c.targets {
+		wg.Add(1)
+		go func(target certificateTarget) {
+			defer wg.Done()
+
+			expiry, err := c.probe(target)
+			if err != nil {
+				level.Warn(c.logger).Log("msg", "Error probing certificate", "address", target.address, "server_name", target.serverName, "error", err.Error())
+				ch <- prometheus.MustNewConstMetric(c.metrics["success"],
+					prometheus.GaugeValue, 0, target.address, target.serverName)
+				return
+			}
+			ch <- prometheus.MustNewConstMetric(c.metrics["success"],
+				prometheus.GaugeValue, 1, target.address, target.serverName)
+			ch <- prometheus.MustNewConstMetric(c.metrics["expiry"],
+				prometheus.GaugeValue, float64(expiry.Unix()), target.address, target.serverName)
+		}(target)
+	}
+	wg.Wait()
+}
+
+// probe returns the expiry time of the leaf certificate. The certificate is not verified, so that expired and
+// self-signed certificates are reported as well.
+func (c *SSLCertificateCollector) probe(target certificateTarget) (time.Time, error) {
+	dialer := &net.Dialer{Timeout: c.timeout}
+	conn, err := tls.DialWithDialer(dialer, "tcp", target.address, &tls.Config{
+		ServerName:         target.serverName,
+		InsecureSkipVerify: true, //nolint:gosec // the expiry of any certificate is reported
+	})
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+
+	certificates := conn.ConnectionState().PeerCertificates
+	if len(certificates) == 0 {
+		return time.Time{}, errors.New("no certificate served")
+	}
+	return certificates[0].NotAfter, nil
+}
+
+func newSSLCertificateMetric(namespace string, metricName string, docString string, constLabels prometheus.Labels) *prometheus.Desc {
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "ssl_certificate", metricName), docString, []string{"address", "server_name"}, constLabels)
+}
+
+// newSSLCertificateFactory registers the flags of the probes of the certificates served by NGINX.
+func newSSLCertificateFactory() Factory {
+	var probes *[]string
+	return Factory{
+		Name: "ssl_certificate",
+		Flags: func(app *kingpin.Application) {
+			probes = app.Flag("nginx.ssl-certificate-probe", "An address of NGINX to probe the served certificate at on every scrape, given as host:port to send the host as the server name, or as host:port=name1,name2 to probe every server name, e.g. 127.0.0.1:443=example.com,www.example.com. It can be repeated. The expiry time of the certificates is exported.").Strings()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if len(*probes) == 0 {
+				return nil, nil
+			}
+			c, err := NewSSLCertificateCollector(*probes, env.Timeout, "nginx", env.ConstLabels, env.Logger)
+			if err != nil {
+				return nil, err
+			}
+			return c, nil
+		},
+	}
+}
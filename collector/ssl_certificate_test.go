@@ -0,0 +1,89 @@
+package collector
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestParseCertificateProbe(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		probe   string
+		want    []certificateTarget
+		wantErr bool
+	}{
+		{
+			name:  "host name",
+			probe: "example.com:443",
+			want:  []certificateTarget{{address: "example.com:443", serverName: "example.com"}},
+		},
+		{
+			name:  "ip address",
+			probe: "127.0.0.1:443",
+			want:  []certificateTarget{{address: "127.0.0.1:443"}},
+		},
+		{
+			name:  "server names",
+			probe: "127.0.0.1:443=example.com, www.example.com",
+			want: []certificateTarget{
+				{address: "127.0.0.1:443", serverName: "example.com"},
+				{address: "127.0.0.1:443", serverName: "www.example.com"},
+			},
+		},
+		{
+			name:    "no port",
+			probe:   "example.com",
+			wantErr: true,
+		},
+		{
+			name:    "no server names",
+			probe:   "127.0.0.1:443=",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseCertificateProbe(tt.probe)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseCertificateProbe() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseCertificateProbe() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSLCertificateCollector(t *testing.T) {
+	t.Parallel()
+
+	server := httptest.NewTLSServer(http.NotFoundHandler())
+	defer server.Close()
+	address := server.Listener.Addr().String()
+
+	collector, err := NewSSLCertificateCollector([]string{address + "=example.com"}, time.Second, "nginx", nil, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewSSLCertificateCollector() error = %v", err)
+	}
+
+	expected := fmt.Sprintf(`# HELP nginx_ssl_certificate_expiry_timestamp_seconds Expiry time of the certificate served for the server name since unix epoch in seconds
+# TYPE nginx_ssl_certificate_expiry_timestamp_seconds gauge
+nginx_ssl_certificate_expiry_timestamp_seconds{address=%q,server_name="example.com"} %v
+# HELP nginx_ssl_certificate_probe_success Whether the certificate served for the server name could be fetched
+# TYPE nginx_ssl_certificate_probe_success gauge
+nginx_ssl_certificate_probe_success{address=%q,server_name="example.com"} 1
+`, address, server.Certificate().NotAfter.Unix(), address)
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,155 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/streamsts"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// StreamStsCollector collects the traffic of the TCP/UDP proxies from the nginx-module-stream-sts. It implements prometheus.Collector interface.
+type StreamStsCollector struct {
+	nginxClient  *streamsts.NginxClient
+	metrics      map[string]*prometheus.Desc
+	upMetric     prometheus.Gauge
+	scrapeErrors *prometheus.CounterVec
+	lastError    *prometheus.Desc
+	mutex        sync.Mutex
+	logger       log.Logger
+}
+
+// NewStreamStsCollector creates a StreamStsCollector. The metrics are prefixed with namespace_stream_sts.
+func NewStreamStsCollector(nginxClient *streamsts.NginxClient, namespace string, constLabels map[string]string, logger log.Logger) *StreamStsCollector {
+	namespace += "_stream_sts"
+	return &StreamStsCollector{
+		nginxClient: nginxClient,
+		logger:      logger,
+		metrics: map[string]*prometheus.Desc{
+			"server_connects":  newStreamStsServerZoneMetric(namespace, "connects", "Total connections", nil, constLabels),
+			"server_sessions":  newStreamStsServerZoneMetric(namespace, "sessions", "Total sessions completed", []string{"code"}, constLabels),
+			"server_received":  newStreamStsServerZoneMetric(namespace, "received", "Bytes received from clients", nil, constLabels),
+			"server_sent":      newStreamStsServerZoneMetric(namespace, "sent", "Bytes sent to clients", nil, constLabels),
+			"upstream_connect": newStreamStsUpstreamServerMetric(namespace, "connects", "Total connections", nil, constLabels),
+			"upstream_session": newStreamStsUpstreamServerMetric(namespace, "sessions", "Total sessions completed", []string{"code"}, constLabels),
+			"upstream_recv":    newStreamStsUpstreamServerMetric(namespace, "received", "Bytes received from clients proxied to the server", nil, constLabels),
+			"upstream_sent":    newStreamStsUpstreamServerMetric(namespace, "sent", "Bytes sent to clients proxied to the server", nil, constLabels),
+			"upstream_down":    newStreamStsUpstreamServerMetric(namespace, "down", "Whether the server is marked as down in the configuration", nil, constLabels),
+		},
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("stream_sts", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of the stream traffic metrics
+// to the provided channel.
+func (c *StreamStsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
+
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect fetches the stream traffic from the display page and sends it to the provided channel.
+func (c *StreamStsCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	status, err := c.nginxClient.GetStatus(context.Background())
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
+		level.Error(c.logger).Log("msg", "Error getting stream traffic status", "error", err.Error())
+		return
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
+
+	for name, zone := range status.StreamServerZones {
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_connects"],
+			prometheus.CounterValue, float64(zone.ConnectCounter), name)
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_received"],
+			prometheus.CounterValue, float64(zone.InBytes), name)
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_sent"],
+			prometheus.CounterValue, float64(zone.OutBytes), name)
+		c.collectSessions(ch, c.metrics["server_sessions"], zone.Responses, name)
+	}
+
+	for upstream, servers := range status.StreamUpstreamZones {
+		for _, server := range servers {
+			ch <- prometheus.MustNewConstMetric(c.metrics["upstream_connect"],
+				prometheus.CounterValue, float64(server.ConnectCounter), upstream, server.Server)
+			ch <- prometheus.MustNewConstMetric(c.metrics["upstream_recv"],
+				prometheus.CounterValue, float64(server.InBytes), upstream, server.Server)
+			ch <- prometheus.MustNewConstMetric(c.metrics["upstream_sent"],
+				prometheus.CounterValue, float64(server.OutBytes), upstream, server.Server)
+			var down float64
+			if server.Down {
+				down = 1.0
+			}
+			ch <- prometheus.MustNewConstMetric(c.metrics["upstream_down"],
+				prometheus.GaugeValue, down, upstream, server.Server)
+			c.collectSessions(ch, c.metrics["upstream_session"], server.Responses, upstream, server.Server)
+		}
+	}
+}
+
+// collectSessions sends the sessions by the class of the session status code. The labels of the zone precede the code.
+func (c *StreamStsCollector) collectSessions(ch chan<- prometheus.Metric, desc *prometheus.Desc, responses streamsts.Responses, labelValues ...string) {
+	for code, value := range map[string]uint64{
+		"1xx": responses.Responses1xx,
+		"2xx": responses.Responses2xx,
+		"3xx": responses.Responses3xx,
+		"4xx": responses.Responses4xx,
+		"5xx": responses.Responses5xx,
+	} {
+		ch <- prometheus.MustNewConstMetric(desc,
+			prometheus.CounterValue, float64(value), append(labelValues, code)...)
+	}
+}
+
+func newStreamStsServerZoneMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := append([]string{"server_zone"}, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "server_zone", metricName), docString, labels, constLabels)
+}
+
+func newStreamStsUpstreamServerMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := append([]string{"upstream", "server"}, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "upstream_server", metricName), docString, labels, constLabels)
+}
+
+// newStreamStsFactory registers the flag of the display page of the nginx-module-stream-sts.
+func newStreamStsFactory() Factory {
+	var uri *string
+	return Factory{
+		Name: "stream_sts",
+		Flags: func(app *kingpin.Application) {
+			uri = app.Flag("nginx.stream-sts-uri", "A URI or unix domain socket path of the display page of the nginx-module-stream-sts in the json format, e.g. http://127.0.0.1:8080/stream-status/format/json. When set, the traffic of the TCP/UDP server and upstream zones is exported for NGINX.").Default("").Envar("STREAM_STS_URI").String()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if *uri == "" || env.Plus || env.Unit {
+				return nil, nil
+			}
+			httpClient, scrapeURI, err := env.NewHTTPClient(*uri, "/stream-status/format/json")
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the stream traffic status address %v: %w", *uri, err)
+			}
+			return NewStreamStsCollector(streamsts.NewNginxClient(httpClient, scrapeURI), "nginx", env.ConstLabels, env.Logger), nil
+		},
+	}
+}
@@ -0,0 +1,121 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/alecthomas/kingpin/v2"
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/upstreamcheck"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// UpstreamCheckCollector collects the health checks of the nginx_upstream_check_module. It implements prometheus.Collector interface.
+type UpstreamCheckCollector struct {
+	nginxClient  *upstreamcheck.NginxClient
+	metrics      map[string]*prometheus.Desc
+	upMetric     prometheus.Gauge
+	scrapeErrors *prometheus.CounterVec
+	lastError    *prometheus.Desc
+	mutex        sync.Mutex
+	logger       log.Logger
+}
+
+// NewUpstreamCheckCollector creates an UpstreamCheckCollector. The metrics are prefixed with namespace_upstream_check.
+func NewUpstreamCheckCollector(nginxClient *upstreamcheck.NginxClient, namespace string, constLabels map[string]string, logger log.Logger) *UpstreamCheckCollector {
+	namespace += "_upstream_check"
+	return &UpstreamCheckCollector{
+		nginxClient: nginxClient,
+		logger:      logger,
+		metrics: map[string]*prometheus.Desc{
+			"generation":  newGlobalMetric(namespace, "generation", "Generation of the checked upstream configuration", constLabels),
+			"server_up":   newUpstreamCheckServerMetric(namespace, "up", "Whether the server passed the health checks", []string{"type"}, constLabels),
+			"server_rise": newUpstreamCheckServerMetric(namespace, "rise", "Consecutive successful health checks", nil, constLabels),
+			"server_fall": newUpstreamCheckServerMetric(namespace, "fall", "Consecutive failed health checks", nil, constLabels),
+		},
+		upMetric:     newUpMetric(namespace, constLabels),
+		scrapeErrors: newScrapeErrorsMetric("upstream_check", constLabels),
+		lastError:    newLastScrapeErrorMetric(namespace, constLabels),
+	}
+}
+
+// Describe sends the super-set of all possible descriptors of the health check metrics
+// to the provided channel.
+func (c *UpstreamCheckCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.upMetric.Desc()
+	c.scrapeErrors.Describe(ch)
+	ch <- c.lastError
+
+	for _, m := range c.metrics {
+		ch <- m
+	}
+}
+
+// Collect fetches the health checks from the check_status page and sends them to the provided channel.
+func (c *UpstreamCheckCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mutex.Lock() // To protect metrics from concurrent collects
+	defer c.mutex.Unlock()
+
+	status, err := c.nginxClient.GetStatus(context.Background())
+	if err != nil {
+		c.upMetric.Set(nginxDown)
+		ch <- c.upMetric
+		reason := classifyError(err)
+		c.scrapeErrors.WithLabelValues(reason).Inc()
+		c.scrapeErrors.Collect(ch)
+		ch <- prometheus.MustNewConstMetric(c.lastError,
+			prometheus.GaugeValue, 1, reason)
+		level.Error(c.logger).Log("msg", "Error getting upstream health checks", "error", err.Error())
+		return
+	}
+
+	c.upMetric.Set(nginxUp)
+	ch <- c.upMetric
+	c.scrapeErrors.Collect(ch)
+
+	// the csv format does not report the generation
+	if status.Servers.Generation > 0 {
+		ch <- prometheus.MustNewConstMetric(c.metrics["generation"],
+			prometheus.GaugeValue, float64(status.Servers.Generation))
+	}
+	for _, server := range status.Servers.Server {
+		var up float64
+		if server.Status == "up" {
+			up = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_up"],
+			prometheus.GaugeValue, up, server.Upstream, server.Name, server.Type)
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_rise"],
+			prometheus.GaugeValue, float64(server.Rise), server.Upstream, server.Name)
+		ch <- prometheus.MustNewConstMetric(c.metrics["server_fall"],
+			prometheus.GaugeValue, float64(server.Fall), server.Upstream, server.Name)
+	}
+}
+
+func newUpstreamCheckServerMetric(namespace string, metricName string, docString string, variableLabelNames []string, constLabels prometheus.Labels) *prometheus.Desc {
+	labels := append([]string{"upstream", "server"}, variableLabelNames...)
+	return prometheus.NewDesc(prometheus.BuildFQName(namespace, "server", metricName), docString, labels, constLabels)
+}
+
+// newUpstreamCheckFactory registers the flag of the check_status page of the nginx_upstream_check_module.
+func newUpstreamCheckFactory() Factory {
+	var uri *string
+	return Factory{
+		Name: "upstream_check",
+		Flags: func(app *kingpin.Application) {
+			uri = app.Flag("nginx.upstream-check-uri", "A URI or unix domain socket path of the check_status page of the nginx_upstream_check_module in the json or csv format, e.g. http://127.0.0.1:8080/status?format=json. When set, the health checks of the upstream servers are exported for NGINX.").Default("").Envar("UPSTREAM_CHECK_URI").String()
+		},
+		New: func(env Environment) (prometheus.Collector, error) {
+			if *uri == "" || env.Plus || env.Unit {
+				return nil, nil
+			}
+			httpClient, scrapeURI, err := env.NewHTTPClient(*uri, "/status?format=json")
+			if err != nil {
+				return nil, fmt.Errorf("failed to parse the upstream check address %v: %w", *uri, err)
+			}
+			return NewUpstreamCheckCollector(upstreamcheck.NewNginxClient(httpClient, scrapeURI), "nginx", env.ConstLabels, env.Logger), nil
+		},
+	}
+}
@@ -0,0 +1,66 @@
+package collector
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+
+	"gopkg.in/yaml.v2"
+)
+
+// otherUserAgent is the class of the user agents that match no rule.
+const otherUserAgent = "other"
+
+// UserAgentClasses classify the user agents logged in the access log into a few classes, e.g. browser or bot.
+type UserAgentClasses struct {
+	// Classes are checked in their order, the first class with a matching pattern is used.
+	Classes []UserAgentClass `yaml:"classes"`
+
+	patterns [][]*regexp.Regexp
+}
+
+// UserAgentClass is a class of the user agents that match any of the regular expressions of Patterns.
+type UserAgentClass struct {
+	Class    string   `yaml:"class"`
+	Patterns []string `yaml:"patterns"`
+}
+
+// LoadUserAgentClasses reads UserAgentClasses from a YAML file.
+func LoadUserAgentClasses(path string) (*UserAgentClasses, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the user agent classes: %w", err)
+	}
+	var classes UserAgentClasses
+	if err := yaml.UnmarshalStrict(data, &classes); err != nil {
+		return nil, fmt.Errorf("failed to parse the user agent classes %v: %w", path, err)
+	}
+	for _, class := range classes.Classes {
+		if class.Class == "" {
+			return nil, errors.New("invalid user agent class: the class must have a name")
+		}
+		var patterns []*regexp.Regexp
+		for _, pattern := range class.Patterns {
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern of the user agent class %v: %w", class.Class, err)
+			}
+			patterns = append(patterns, re)
+		}
+		classes.patterns = append(classes.patterns, patterns)
+	}
+	return &classes, nil
+}
+
+// Classify returns the first class with a pattern that matches the user agent, or other.
+func (c *UserAgentClasses) Classify(userAgent string) string {
+	for i, patterns := range c.patterns {
+		for _, pattern := range patterns {
+			if pattern.MatchString(userAgent) {
+				return c.Classes[i].Class
+			}
+		}
+	}
+	return otherUserAgent
+}
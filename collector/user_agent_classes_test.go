@@ -0,0 +1,97 @@
+package collector
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-kit/log"
+	"github.com/nginxinc/nginx-prometheus-exporter/logformat"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+const testUserAgentClasses = `classes:
+  - class: monitoring
+    patterns: ["kube-probe/", "Prometheus/"]
+  - class: bot
+    patterns: ["(?i)bot\\b", "(?i)crawler|spider"]
+  - class: mobile
+    patterns: ["Mobile", "Android"]
+  - class: browser
+    patterns: ["^Mozilla/"]
+`
+
+func loadTestUserAgentClasses(t *testing.T, data string) (*UserAgentClasses, error) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "user-agents.yml")
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	return LoadUserAgentClasses(path)
+}
+
+func TestUserAgentClassesClassify(t *testing.T) {
+	t.Parallel()
+
+	classes, err := loadTestUserAgentClasses(t, testUserAgentClasses)
+	if err != nil {
+		t.Fatalf("LoadUserAgentClasses() error = %v", err)
+	}
+
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{userAgent: "kube-probe/1.28", want: "monitoring"},
+		{userAgent: "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", want: "bot"},
+		{userAgent: "Mozilla/5.0 (Linux; Android 14) AppleWebKit/537.36 Chrome/118.0 Mobile Safari/537.36", want: "mobile"},
+		{userAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:109.0) Gecko/20100101 Firefox/118.0", want: "browser"},
+		{userAgent: "curl/8.1.2", want: "other"},
+		{userAgent: "-", want: "other"},
+	}
+	for _, tt := range tests {
+		if got := classes.Classify(tt.userAgent); got != tt.want {
+			t.Errorf("Classify(%q) = %v, want %v", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+func TestLoadUserAgentClassesInvalid(t *testing.T) {
+	t.Parallel()
+
+	if _, err := loadTestUserAgentClasses(t, "classes:\n  - patterns: [bot]\n"); err == nil {
+		t.Error("LoadUserAgentClasses() of a class without a name error = nil, want an error")
+	}
+	if _, err := loadTestUserAgentClasses(t, "classes:\n  - class: bot\n    patterns: [\"(bot\"]\n"); err == nil {
+		t.Error("LoadUserAgentClasses() of an invalid pattern error = nil, want an error")
+	}
+}
+
+func TestNginxAccessLogCollectorUserAgentClasses(t *testing.T) {
+	t.Parallel()
+
+	classes, err := loadTestUserAgentClasses(t, testUserAgentClasses)
+	if err != nil {
+		t.Fatalf("LoadUserAgentClasses() error = %v", err)
+	}
+	format, err := logformat.New(`$host "$request" $status "$http_user_agent"`)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	collector := NewNginxAccessLogCollector(format, false, "nginx", nil, log.NewNopLogger())
+	collector.SetUserAgentClasses(classes)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 "Mozilla/5.0 (compatible; bingbot/2.0)"`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 "Mozilla/5.0 (compatible; YandexBot/3.0)"`)
+	collector.HandleLine(`example.com "GET / HTTP/1.1" 200 "curl/8.1.2"`)
+
+	expected := `# HELP nginx_http_user_agent_requests_total Total requests logged in the access log by the class of the user agent
+# TYPE nginx_http_user_agent_requests_total counter
+nginx_http_user_agent_requests_total{class="bot",vhost="example.com"} 2
+nginx_http_user_agent_requests_total{class="other",vhost="example.com"} 1
+`
+	if err := testutil.CollectAndCompare(collector, strings.NewReader(expected), "nginx_http_user_agent_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	nginxclient "github.com/nginxinc/nginx-prometheus-exporter/client"
+)
+
+// The types of the scrape URI detected by detectScrapeURI.
+const (
+	scrapeURIStubStatus = "stub_status"
+	scrapeURIPlus       = "plus"
+	scrapeURIUnit       = "unit"
+)
+
+// maxDetectBodySize limits the body read to detect the type of the scrape URI.
+const maxDetectBodySize = 1 << 20
+
+// detectScrapeURI fetches the scrape URI and detects whether it is a stub_status page, the root of the NGINX Plus API or
+// the status of the NGINX Unit control API.
+func detectScrapeURI(ctx context.Context, httpClient *http.Client, scrapeURI string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scrapeURI, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create a get request: %w", err)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get %v: %w", scrapeURI, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", &nginxclient.StatusError{StatusCode: resp.StatusCode}
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxDetectBodySize))
+	if err != nil {
+		return "", fmt.Errorf("failed to read the response body: %w", err)
+	}
+
+	scrapeURIType, ok := detectScrapeURIType(body)
+	if !ok {
+		return "", errors.New("the response is neither a stub_status page, the root of the NGINX Plus API, e.g. /api, nor the status of the NGINX Unit control API, e.g. /status")
+	}
+	return scrapeURIType, nil
+}
+
+func detectScrapeURIType(body []byte) (string, bool) {
+	body = bytes.TrimSpace(body)
+	if bytes.HasPrefix(body, []byte("Active connections:")) {
+		return scrapeURIStubStatus, true
+	}
+
+	// the root of the NGINX Plus API lists the API versions
+	var versions []int
+	if err := json.Unmarshal(body, &versions); err == nil && len(versions) > 0 {
+		return scrapeURIPlus, true
+	}
+
+	var status map[string]json.RawMessage
+	if err := json.Unmarshal(body, &status); err == nil {
+		_, connections := status["connections"]
+		_, applications := status["applications"]
+		if connections && applications {
+			return scrapeURIUnit, true
+		}
+	}
+	return "", false
+}
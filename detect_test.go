@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDetectScrapeURI(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:       "stub_status",
+			statusCode: http.StatusOK,
+			body:       "Active connections: 1 \nserver accepts handled requests\n 5 5 10 \nReading: 0 Writing: 1 Waiting: 0 \n",
+			want:       scrapeURIStubStatus,
+		},
+		{
+			name:       "NGINX Plus API",
+			statusCode: http.StatusOK,
+			body:       "[1,2,3,4,5,6,7,8,9]",
+			want:       scrapeURIPlus,
+		},
+		{
+			name:       "NGINX Unit status",
+			statusCode: http.StatusOK,
+			body:       `{"connections": {"accepted": 1, "active": 0, "idle": 0, "closed": 1}, "requests": {"total": 1}, "applications": {}}`,
+			want:       scrapeURIUnit,
+		},
+		{
+			name:       "NGINX Plus API version",
+			statusCode: http.StatusOK,
+			body:       `["nginx","processes","connections","slabs","http","stream","resolvers","ssl","workers"]`,
+			wantErr:    true,
+		},
+		{
+			name:       "not found",
+			statusCode: http.StatusNotFound,
+			body:       "<html>not found</html>",
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			got, err := detectScrapeURI(context.Background(), server.Client(), server.URL)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detectScrapeURI() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("detectScrapeURI() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -15,7 +15,6 @@ import (
 	"syscall"
 	"time"
 
-	plusclient "github.com/nginxinc/nginx-plus-go-client/client"
 	"github.com/nginxinc/nginx-prometheus-exporter/client"
 	"github.com/nginxinc/nginx-prometheus-exporter/collector"
 
@@ -56,10 +55,10 @@ func parsePositiveDuration(s string) (positiveDuration, error) {
 	return positiveDuration{dur}, nil
 }
 
-func createPositiveDurationFlag(s kingpin.Settings) (target *time.Duration) {
-	target = new(time.Duration)
-	s.SetValue(&positiveDuration{Duration: *target})
-	return
+func createPositiveDurationFlag(s kingpin.Settings) *time.Duration {
+	pd := &positiveDuration{}
+	s.SetValue(pd)
+	return &pd.Duration
 }
 
 func createClientWithRetries(getClient func() (interface{}, error), retries uint, retryInterval time.Duration, logger log.Logger) (interface{}, error) {
@@ -83,7 +82,7 @@ func parseUnixSocketAddress(address string) (string, string, error) {
 	addressParts := strings.Split(address, ":")
 	addressPartsLength := len(addressParts)
 
-	if addressPartsLength > 3 || addressPartsLength < 1 {
+	if addressPartsLength > 3 || addressPartsLength < 2 || addressParts[1] == "" {
 		return "", "", fmt.Errorf("address for unix domain socket has wrong format")
 	}
 
@@ -95,6 +94,50 @@ func parseUnixSocketAddress(address string) (string, string, error) {
 	return unixSocketPath, requestPath, nil
 }
 
+// createHTTPClient creates an HTTP client for the scrape URI. For a unix domain socket address the client dials the socket
+// and the returned URI is rewritten to an HTTP URI with the request path, or defaultRequestPath if the address has none.
+func createHTTPClient(scrapeURI string, defaultRequestPath string, sslConfig *tls.Config, userAgent string, timeout time.Duration) (*http.Client, string, error) {
+	transport := &http.Transport{
+		TLSClientConfig: sslConfig,
+	}
+	if strings.HasPrefix(scrapeURI, "unix:") {
+		socketPath, requestPath, err := parseUnixSocketAddress(scrapeURI)
+		if err != nil {
+			return nil, "", err
+		}
+
+		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
+			return net.Dial("unix", socketPath)
+		}
+		if requestPath == "" {
+			requestPath = defaultRequestPath
+		}
+		scrapeURI = "http://unix" + requestPath
+	}
+
+	httpClient := &http.Client{
+		Timeout: timeout,
+		Transport: &userAgentRoundTripper{
+			agent: userAgent,
+			rt:    transport,
+		},
+	}
+	return httpClient, scrapeURI, nil
+}
+
+// splitScrapeURIs splits comma separated scrape URIs given to the repeatable scrape URI flag.
+func splitScrapeURIs(uris []string) []string {
+	var result []string
+	for _, uri := range uris {
+		for _, u := range strings.Split(uri, ",") {
+			if u = strings.TrimSpace(u); u != "" {
+				result = append(result, u)
+			}
+		}
+	}
+	return result
+}
+
 var (
 	constLabels = map[string]string{}
 
@@ -102,20 +145,43 @@ var (
 	webConfig     = kingpinflag.AddFlags(kingpin.CommandLine, ":9113")
 	metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").Envar("TELEMETRY_PATH").String()
 	nginxPlus     = kingpin.Flag("nginx.plus", "Start the exporter for NGINX Plus. By default, the exporter is started for NGINX.").Default("false").Envar("NGINX_PLUS").Bool()
+	nginxDetect   = kingpin.Flag("nginx.detect", "Detect on start whether the scrape URI is a stub_status page, the root of the NGINX Plus API or the status of the NGINX Unit control API and start the exporter for it, instead of -nginx.plus and -nginx.unit. The detection is retried like the connection to NGINX.").Default("false").Envar("NGINX_DETECT").Bool()
 	nginxUnit     = kingpin.Flag("nginx.unit", "Start the exporter for NGINX Unit. By default, the exporter is started for NGINX.").Default("false").Envar("NGINX_UNIT").Bool()
-	scrapeURI     = kingpin.Flag("nginx.scrape-uri", "A URI or unix domain socket path for scraping NGINX, NGINX Plus, NGINX Unit metrics. For NGINX, the stub_status page must be available through the URI. For NGINX Plus -- the API.").Default("http://127.0.0.1:8080/stub_status").String()
+	scrapeURIs    = kingpin.Flag("nginx.scrape-uri", "A URI or unix domain socket path for scraping NGINX, NGINX Plus, NGINX Unit metrics. For NGINX Unit, it can be repeated or given as a comma separated list to scrape several instances. For NGINX, the stub_status page must be available through the URI. For NGINX Plus -- the API. For NGINX Unit -- the /status endpoint of the control API, e.g. unix:/var/run/control.unit.sock:/status.").Default("http://127.0.0.1:8080/stub_status").Strings()
 	sslVerify     = kingpin.Flag("nginx.ssl-verify", "Perform SSL certificate verification.").Default("false").Envar("SSL_VERIFY").Bool()
 	sslCaCert     = kingpin.Flag("nginx.ssl-ca-cert", "Path to the PEM encoded CA certificate file used to validate the servers SSL certificate.").Default("").Envar("SSL_CA_CERT").String()
 	sslClientCert = kingpin.Flag("nginx.ssl-client-cert", "Path to the PEM encoded client certificate file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_CERT").String()
 	sslClientKey  = kingpin.Flag("nginx.ssl-client-key", "Path to the PEM encoded client certificate key file to use when connecting to the server.").Default("").Envar("SSL_CLIENT_KEY").String()
+	nginxMaxConns = kingpin.Flag("nginx.max-connections", "The maximum number of client connections of NGINX or NGINX Plus, i.e. the worker_connections multiplied by the number of the worker processes. When set, it is exported as the connections limit.").Default("0").Envar("NGINX_MAX_CONNECTIONS").Uint64()
+	nativeHists   = kingpin.Flag("nginx.native-histograms", "Export the request duration and size histograms of the NGINX and NGINX Unit access logs as native histograms with sparse buckets instead of the classic buckets. Native histograms are only exposed in the protobuf format and must be enabled in Prometheus with --enable-feature=native-histograms.").Default("false").Envar("NGINX_NATIVE_HISTOGRAMS").Bool()
 	nginxRetries  = kingpin.Flag("nginx.retries", "A number of retries the exporter will make on start to connect to the NGINX stub_status page/NGINX Plus API before exiting with an error.").Default("0").Envar("NGINX_RETRIES").Uint()
 
+	unitNamespace = kingpin.Flag("unit.telemetry-namespace", "Namespace (prefix) of the NGINX Unit metrics.").Default("nginxunit").Envar("UNIT_TELEMETRY_NAMESPACE").String()
+
+	unitCollectConnections  = kingpin.Flag("unit.collect.connections", "Export the NGINX Unit connections metrics.").Default("true").Envar("UNIT_COLLECT_CONNECTIONS").Bool()
+	unitCollectRequests     = kingpin.Flag("unit.collect.requests", "Export the NGINX Unit total http requests metric.").Default("true").Envar("UNIT_COLLECT_REQUESTS").Bool()
+	unitCollectApplications = kingpin.Flag("unit.collect.applications", "Export the NGINX Unit application metrics.").Default("true").Envar("UNIT_COLLECT_APPLICATIONS").Bool()
+	unitCollectListeners    = kingpin.Flag("unit.collect.listeners", "Export the NGINX Unit listener metrics.").Default("true").Envar("UNIT_COLLECT_LISTENERS").Bool()
+	unitCollectRoutes       = kingpin.Flag("unit.collect.routes", "Export the NGINX Unit route metrics.").Default("true").Envar("UNIT_COLLECT_ROUTES").Bool()
+	unitCollectConfig       = kingpin.Flag("unit.collect.config", "Export the NGINX Unit configuration hash and last change time.").Default("true").Envar("UNIT_COLLECT_CONFIG").Bool()
+	unitCollectUpstreams    = kingpin.Flag("unit.collect.upstreams", "Export the NGINX Unit upstream metrics.").Default("true").Envar("UNIT_COLLECT_UPSTREAMS").Bool()
+	unitCollectCertificates = kingpin.Flag("unit.collect.certificates", "Export the NGINX Unit certificate expiry metrics.").Default("true").Envar("UNIT_COLLECT_CERTIFICATES").Bool()
+
+	unitSocketGlob = kingpin.Flag("unit.socket-glob", "Glob pattern of NGINX Unit control sockets to discover and scrape, e.g. /var/run/unit/*.sock. The sockets are scraped instead of the scrape URIs.").Default("").Envar("UNIT_SOCKET_GLOB").String()
+	unitPing       = kingpin.Flag("unit.ping", "Check that the status of every NGINX Unit instance can be fetched, print a summary of it and exit. The exit code is non-zero if any of the checks fails. Requires -nginx.unit.").Default("false").Envar("UNIT_PING").Bool()
+
 	// Custom command-line flags
-	timeout            = createPositiveDurationFlag(kingpin.Flag("nginx.timeout", "A timeout for scraping metrics from NGINX or NGINX Plus.").Default("5s").Envar("TIMEOUT"))
-	nginxRetryInterval = createPositiveDurationFlag(kingpin.Flag("nginx.retry-interval", "An interval between retries to connect to the NGINX stub_status page/NGINX Plus API on start.").Default("5s").Envar("NGINX_RETRY_INTERVAL"))
+	timeout                  = createPositiveDurationFlag(kingpin.Flag("nginx.timeout", "A timeout for scraping metrics from NGINX or NGINX Plus.").Default("5s").Envar("TIMEOUT"))
+	nginxRetryInterval       = createPositiveDurationFlag(kingpin.Flag("nginx.retry-interval", "An interval between retries to connect to the NGINX stub_status page/NGINX Plus API on start.").Default("5s").Envar("NGINX_RETRY_INTERVAL"))
+	unitTimeout              = createPositiveDurationFlag(kingpin.Flag("unit.timeout", "A timeout for all the NGINX Unit control API requests of a scrape.").Default("10s").Envar("UNIT_TIMEOUT"))
+	unitSocketRescanInterval = createPositiveDurationFlag(kingpin.Flag("unit.socket-rescan-interval", "An interval between the discoveries of the NGINX Unit control sockets matching the socket glob.").Default("30s").Envar("UNIT_SOCKET_RESCAN_INTERVAL"))
 )
 
-const exporterName = "nginx_exporter"
+const (
+	exporterName = "nginx_exporter"
+	// metricsNamespace is the namespace of the metrics about the exporter itself, e.g. the collector durations.
+	metricsNamespace = collector.MetricsNamespace
+)
 
 func main() {
 	kingpin.Flag("prometheus.const-label", "Label that will be used in every metric. Format is label=value. It can be repeated multiple times.").Envar("CONST_LABELS").StringMapVar(&constLabels)
@@ -132,10 +198,19 @@ func main() {
 		}
 	}
 
+	for _, factory := range collector.Factories() {
+		factory.Flags(kingpin.CommandLine)
+	}
 	flag.AddFlags(kingpin.CommandLine, promlogConfig)
 	kingpin.Version(version.Print(exporterName))
 	kingpin.HelpFlag.Short('h')
 	kingpin.Parse()
+	*scrapeURIs = splitScrapeURIs(*scrapeURIs)
+
+	if len(*scrapeURIs) == 0 {
+		level.Error(logger).Log("msg", "A scrape URI must be set")
+		os.Exit(1)
+	}
 
 	level.Info(logger).Log("msg", "Starting nginx-prometheus-exporter", "version", version.Info())
 	level.Info(logger).Log("msg", "Build context", "build_context", version.BuildContext())
@@ -159,6 +234,11 @@ func main() {
 		sslConfig.RootCAs = sslCaCertPool
 	}
 
+	if (*sslClientCert != "") != (*sslClientKey != "") {
+		level.Error(logger).Log("msg", "Both the client certificate and the client certificate key must be set for TLS client authentication")
+		os.Exit(1)
+	}
+
 	if *sslClientCert != "" && *sslClientKey != "" {
 		clientCert, err := tls.LoadX509KeyPair(*sslClientCert, *sslClientKey)
 		if err != nil {
@@ -168,62 +248,159 @@ func main() {
 		sslConfig.Certificates = []tls.Certificate{clientCert}
 	}
 
-	transport := &http.Transport{
-		TLSClientConfig: sslConfig,
-	}
-	if strings.HasPrefix(*scrapeURI, "unix:") {
-		socketPath, requestPath, err := parseUnixSocketAddress(*scrapeURI)
+	userAgent := fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", version.Version)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
+	defer cancel()
+
+	if *nginxDetect {
+		httpClient, scrapeURI, err := createHTTPClient((*scrapeURIs)[0], "", sslConfig, userAgent, *timeout)
 		if err != nil {
-			level.Error(logger).Log("msg", "Parsing unix domain socket scrape address failed", "uri", *scrapeURI, "error", err.Error())
+			level.Error(logger).Log("msg", "Parsing unix domain socket scrape address failed", "uri", (*scrapeURIs)[0], "error", err.Error())
 			os.Exit(1)
 		}
-
-		transport.DialContext = func(_ context.Context, _, _ string) (net.Conn, error) {
-			return net.Dial("unix", socketPath)
+		detected, err := createClientWithRetries(func() (interface{}, error) {
+			return detectScrapeURI(ctx, httpClient, scrapeURI)
+		}, *nginxRetries, *nginxRetryInterval, logger)
+		if err != nil {
+			level.Error(logger).Log("msg", "Could not detect the type of the scrape URI", "uri", (*scrapeURIs)[0], "error", err.Error())
+			os.Exit(1)
 		}
-		newScrapeURI := "http://unix" + requestPath
-		scrapeURI = &newScrapeURI
+		level.Info(logger).Log("msg", "Detected the type of the scrape URI", "uri", (*scrapeURIs)[0], "type", detected)
+		*nginxPlus = detected == scrapeURIPlus
+		*nginxUnit = detected == scrapeURIUnit
 	}
 
-	userAgent := fmt.Sprintf("NGINX-Prometheus-Exporter/v%v", version.Version)
-	userAgentRT := &userAgentRoundTripper{
-		agent: userAgent,
-		rt:    transport,
+	if *unitPing && !*nginxUnit {
+		level.Error(logger).Log("msg", "The NGINX Unit ping is only supported for NGINX Unit, set -nginx.unit")
+		os.Exit(1)
 	}
 
-	httpClient := &http.Client{
-		Timeout:   *timeout,
-		Transport: userAgentRT,
+	if len(*scrapeURIs) > 1 && !*nginxUnit {
+		level.Error(logger).Log("msg", "Multiple scrape URIs are only supported for NGINX Unit", "uris", strings.Join(*scrapeURIs, ","))
+		os.Exit(1)
 	}
 
-	if *nginxPlus {
-		plusClient, err := createClientWithRetries(func() (interface{}, error) {
-			return plusclient.NewNginxClient(*scrapeURI, plusclient.WithHTTPClient(httpClient))
-		}, *nginxRetries, *nginxRetryInterval, logger)
+	if *nginxUnit {
+		unitMetricGroups := collector.NewUnitMetricGroups(*unitCollectConnections, *unitCollectRequests, *unitCollectApplications, *unitCollectListeners, *unitCollectRoutes, *unitCollectConfig, *unitCollectUpstreams, *unitCollectCertificates)
+		newUnitClient := func(uri string) (*unitclient.NginxClient, error) {
+			httpClient, scrapeURI, err := createHTTPClient(uri, "/status", sslConfig, userAgent, *timeout)
+			if err != nil {
+				return nil, err
+			}
+			return unitclient.NewNginxClient(httpClient, scrapeURI)
+		}
+		if *unitPing {
+			uris := *scrapeURIs
+			if *unitSocketGlob != "" {
+				var err error
+				if uris, err = globUnitSockets(*unitSocketGlob); err != nil {
+					level.Error(logger).Log("msg", "Discovering NGINX Unit sockets failed", "pattern", *unitSocketGlob, "error", err.Error())
+					os.Exit(1)
+				}
+			}
+			failed := len(uris) == 0
+			if failed {
+				fmt.Fprintf(os.Stdout, "%v: FAILED: no sockets match the pattern\n", *unitSocketGlob)
+			}
+			for _, uri := range uris {
+				pingCtx, pingCancel := ctx, context.CancelFunc(func() {})
+				if *unitTimeout > 0 {
+					pingCtx, pingCancel = context.WithTimeout(ctx, *unitTimeout)
+				}
+				unitClient, err := newUnitClient(uri)
+				if err == nil {
+					err = pingUnit(pingCtx, os.Stdout, uri, unitClient)
+				}
+				pingCancel()
+				if err != nil {
+					fmt.Fprintf(os.Stdout, "%v: FAILED: %v\n", uri, err)
+					failed = true
+				}
+			}
+			if failed {
+				os.Exit(1)
+			}
+			os.Exit(0)
+		}
+		if *unitSocketGlob != "" {
+			discovery, err := newUnitSocketDiscovery(*unitSocketGlob, *unitSocketRescanInterval, prometheus.DefaultRegisterer, func(uri string) (prometheus.Collector, error) {
+				unitClient, err := newUnitClient(uri)
+				if err != nil {
+					return nil, err
+				}
+				unitLabels := collector.MergeLabels(constLabels, map[string]string{"unit_host": uri})
+				unitCollector := collector.NewNginxUnitCollector(unitClient, *unitNamespace, unitMetricGroups, *unitTimeout, unitLabels, logger)
+				return collector.NewTimedCollector(unitCollector, metricsNamespace, "unit", unitLabels), nil
+			}, logger)
+			if err != nil {
+				level.Error(logger).Log("msg", "Creating NGINX Unit socket discovery failed", "pattern", *unitSocketGlob, "error", err.Error())
+				os.Exit(1)
+			}
+			discovery.scan()
+			go discovery.run(ctx)
+		} else {
+			for _, uri := range *scrapeURIs {
+				ossClient, err := createClientWithRetries(func() (interface{}, error) {
+					return newUnitClient(uri)
+				}, *nginxRetries, *nginxRetryInterval, logger)
+				if err != nil {
+					level.Error(logger).Log("msg", "Could not create Nginx Client", "uri", uri, "error", err.Error())
+					os.Exit(1)
+				}
+				unitLabels := constLabels
+				if len(*scrapeURIs) > 1 {
+					unitLabels = collector.MergeLabels(constLabels, map[string]string{"unit_host": uri})
+				}
+				unitCollector := collector.NewNginxUnitCollector(ossClient.(*unitclient.NginxClient), *unitNamespace, unitMetricGroups, *unitTimeout, unitLabels, logger)
+				prometheus.MustRegister(collector.NewTimedCollector(unitCollector, metricsNamespace, "unit", unitLabels))
+			}
+		}
+	} else if !*nginxPlus {
+		httpClient, scrapeURI, err := createHTTPClient((*scrapeURIs)[0], "", sslConfig, userAgent, *timeout)
 		if err != nil {
-			level.Error(logger).Log("msg", "Could not create Nginx Plus Client", "error", err.Error())
+			level.Error(logger).Log("msg", "Parsing unix domain socket scrape address failed", "uri", (*scrapeURIs)[0], "error", err.Error())
 			os.Exit(1)
 		}
-		variableLabelNames := collector.NewVariableLabelNames(nil, nil, nil, nil, nil, nil)
-		prometheus.MustRegister(collector.NewNginxPlusCollector(plusClient.(*plusclient.NginxClient), "nginxplus", variableLabelNames, constLabels, logger))
-	} else if *nginxUnit {
 		ossClient, err := createClientWithRetries(func() (interface{}, error) {
-			return unitclient.NewNginxClient(httpClient, *scrapeURI)
+			return client.NewNginxClient(httpClient, scrapeURI)
 		}, *nginxRetries, *nginxRetryInterval, logger)
 		if err != nil {
 			level.Error(logger).Log("msg", "Could not create Nginx Client", "error", err.Error())
 			os.Exit(1)
 		}
-		prometheus.MustRegister(collector.NewNginxUnitCollector(ossClient.(*unitclient.NginxClient), "nginxunit", constLabels, logger))
-	} else {
-		ossClient, err := createClientWithRetries(func() (interface{}, error) {
-			return client.NewNginxClient(httpClient, *scrapeURI)
-		}, *nginxRetries, *nginxRetryInterval, logger)
+		ossCollector := collector.NewNginxCollector(ossClient.(*client.NginxClient), "nginx", constLabels, logger)
+		ossCollector.SetConnectionsLimit(*nginxMaxConns)
+		prometheus.MustRegister(collector.NewTimedCollector(ossCollector, metricsNamespace, "stub_status", constLabels))
+	}
+
+	env := collector.Environment{
+		Context:          ctx,
+		ConstLabels:      constLabels,
+		Logger:           logger,
+		Timeout:          *timeout,
+		Plus:             *nginxPlus,
+		Unit:             *nginxUnit,
+		ScrapeURI:        (*scrapeURIs)[0],
+		UnitNamespace:    *unitNamespace,
+		ConnectionsLimit: *nginxMaxConns,
+		NativeHistograms: *nativeHists,
+		NewHTTPClient: func(uri string, defaultRequestPath string) (*http.Client, string, error) {
+			return createHTTPClient(uri, defaultRequestPath, sslConfig, userAgent, *timeout)
+		},
+		Retry: func(create func() (interface{}, error)) (interface{}, error) {
+			return createClientWithRetries(create, *nginxRetries, *nginxRetryInterval, logger)
+		},
+	}
+	for _, factory := range collector.Factories() {
+		c, err := factory.New(env)
 		if err != nil {
-			level.Error(logger).Log("msg", "Could not create Nginx Client", "error", err.Error())
+			level.Error(logger).Log("msg", "Could not create the collector", "collector", factory.Name, "error", err.Error())
 			os.Exit(1)
 		}
-		prometheus.MustRegister(collector.NewNginxCollector(ossClient.(*client.NginxClient), "nginx", constLabels, logger))
+		if c != nil {
+			prometheus.MustRegister(collector.NewTimedCollector(c, metricsNamespace, factory.Name, constLabels))
+		}
 	}
 
 	http.Handle(*metricsPath, promhttp.Handler())
@@ -249,9 +426,6 @@ func main() {
 		http.Handle("/", landingPage)
 	}
 
-	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
-	defer cancel()
-
 	srv := &http.Server{
 		ReadHeaderTimeout: 5 * time.Second,
 	}
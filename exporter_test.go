@@ -159,6 +159,13 @@ func TestParseUnixSocketAddress(t *testing.T) {
 			"",
 			false,
 		},
+		{
+			"Unix socket address without path",
+			"unix:",
+			"",
+			"",
+			true,
+		},
 		{
 			"Unix socket address with too many colons",
 			"unix:/too:/many:colons:",
@@ -183,3 +190,41 @@ func TestParseUnixSocketAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitScrapeURIs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		testInput []string
+		want      []string
+	}{
+		{
+			"Single URI",
+			[]string{"http://127.0.0.1:8080/stub_status"},
+			[]string{"http://127.0.0.1:8080/stub_status"},
+		},
+		{
+			"Repeated URIs",
+			[]string{"unix:/var/run/unit/a.sock", "unix:/var/run/unit/b.sock"},
+			[]string{"unix:/var/run/unit/a.sock", "unix:/var/run/unit/b.sock"},
+		},
+		{
+			"Comma separated URIs",
+			[]string{"http://unit-a/status, http://unit-b/status,", "http://unit-c/status"},
+			[]string{"http://unit-a/status", "http://unit-b/status", "http://unit-c/status"},
+		},
+		{
+			"Empty URI",
+			[]string{""},
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := splitScrapeURIs(tt.testInput); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitScrapeURIs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package logformat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v2"
+)
+
+// JSON is the name of the preset for the lines that are JSON objects, e.g. written with log_format escape=json.
+const JSON = "json"
+
+// JSONFields map the field names of a JSON log format to the names of the variables without the $, e.g. req_time to
+// request_time, when the names of the fields are not the names of the variables.
+type JSONFields struct {
+	Fields map[string]string `yaml:"fields"`
+}
+
+// LoadJSONFields reads JSONFields from a YAML file.
+func LoadJSONFields(path string) (*JSONFields, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the JSON log fields: %w", err)
+	}
+	var fields JSONFields
+	if err := yaml.UnmarshalStrict(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to parse the JSON log fields %v: %w", path, err)
+	}
+	for field, variable := range fields.Fields {
+		if !isVariableName(variable) {
+			return nil, fmt.Errorf("invalid variable %q of the JSON log field %v", variable, field)
+		}
+	}
+	return &fields, nil
+}
+
+// NewJSON creates a Format for the lines that are JSON objects in any order of the fields. The value of a field is the
+// value of the variable that fields maps it to, or of the variable named like the field if it is not mapped.
+func NewJSON(fields map[string]string) *Format {
+	return &Format{json: true, fields: fields}
+}
+
+// parseJSON decodes a line that is a JSON object. The strings are unescaped, the other values are kept as they are
+// written, e.g. a number written without quotes, and null is an empty value.
+func (f *Format) parseJSON(line string) (Entry, error) {
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(line), &object); err != nil {
+		return nil, fmt.Errorf("invalid JSON log line: %w", err)
+	}
+
+	entry := make(Entry, len(object))
+	for field, raw := range object {
+		variable, ok := f.fields[field]
+		if !ok {
+			variable = field
+		}
+		value := string(raw)
+		switch {
+		case bytes.HasPrefix(raw, []byte(`"`)):
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return nil, fmt.Errorf("invalid value of the JSON log field %v: %w", field, err)
+			}
+		case value == "null":
+			value = ""
+		}
+		entry[variable] = value
+	}
+	return entry, nil
+}
@@ -0,0 +1,95 @@
+package logformat
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSON(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		fields  map[string]string
+		line    string
+		want    Entry
+		wantErr bool
+	}{
+		{
+			name: "any order and types",
+			line: `{"status":200,"request_time":0.012,"host":"example.com","http_referer":null,"cached":true}`,
+			want: Entry{"status": "200", "request_time": "0.012", "host": "example.com", "http_referer": "", "cached": "true"},
+		},
+		{
+			name:   "mapped fields",
+			fields: map[string]string{"req_time": "request_time", "ua": "http_user_agent"},
+			line:   `{"req_time":"0.012","ua":"agent \"x\" é","status":"200"}`,
+			want:   Entry{"request_time": "0.012", "http_user_agent": `agent "x" é`, "status": "200"},
+		},
+		{
+			name:    "not JSON",
+			line:    `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2"`,
+			wantErr: true,
+		},
+		{
+			name:    "default escaping",
+			line:    `{"http_user_agent":"agent \x22x\x22"}`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewJSON(tt.fields).Parse(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadJSONFields(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		data    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "fields",
+			data: "fields:\n  req_time: request_time\n  vhost: server_name\n",
+			want: map[string]string{"req_time": "request_time", "vhost": "server_name"},
+		},
+		{
+			name:    "invalid variable",
+			data:    "fields:\n  req_time: $request_time\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			data:    "mapping:\n  req_time: request_time\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "fields.yml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := LoadJSONFields(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadJSONFields() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got.Fields, tt.want) {
+				t.Errorf("LoadJSONFields() fields = %v, want %v", got.Fields, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package logformat parses the lines of an nginx log written with a log_format directive.
+package logformat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Combined is the predefined combined format of nginx.
+const Combined = `$remote_addr - $remote_user [$time_local] "$request" $status $body_bytes_sent "$http_referer" "$http_user_agent"`
+
+// presets are the log formats that can be given by name: the combined format, the main format of the default
+// nginx.conf and the upstreaminfo format of the Kubernetes ingress-nginx controller. The JSON preset is parsed by
+// NewJSON.
+var presets = map[string]string{
+	"combined": Combined,
+	"main":     Combined + ` "$http_x_forwarded_for"`,
+	"kube-ingress": Combined + ` $request_length $request_time [$proxy_upstream_name] [$proxy_alternative_upstream_name] ` +
+		`$upstream_addr $upstream_response_length $upstream_response_time $upstream_status $req_id`,
+}
+
+// Presets returns the names of the log formats that can be given to New instead of a log format.
+func Presets() []string {
+	names := []string{JSON}
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Entry holds the values of the variables of a log line by the variable names without the $.
+type Entry map[string]string
+
+// Format parses the lines of a log format.
+type Format struct {
+	// tokens alternate between literal text and variables, a variable is followed by a literal or is the last token
+	tokens []token
+	// json is set for the lines that are JSON objects, fields maps the field names to the variable names
+	json   bool
+	fields map[string]string
+}
+
+type token struct {
+	literal  string
+	variable string
+}
+
+// New compiles a log format given like in the log_format directive, e.g. `$remote_addr [$time_local] "$request"`, or
+// by the name of a preset, e.g. combined. Every two variables must be separated by a literal text, otherwise the lines
+// could not be split between them.
+func New(format string) (*Format, error) {
+	if format == JSON {
+		return NewJSON(nil), nil
+	}
+	if preset, ok := presets[format]; ok {
+		format = preset
+	}
+
+	var tokens []token
+	var literal strings.Builder
+	for i := 0; i < len(format); {
+		if format[i] != '$' {
+			literal.WriteByte(format[i])
+			i++
+			continue
+		}
+
+		name, n := variableName(format[i+1:])
+		if name == "" {
+			return nil, fmt.Errorf("invalid variable at %q", format[i:])
+		}
+		if literal.Len() > 0 {
+			tokens = append(tokens, token{literal: literal.String()})
+			literal.Reset()
+		} else if len(tokens) > 0 {
+			return nil, fmt.Errorf("variables %v and %v are not separated by a text", tokens[len(tokens)-1].variable, name)
+		}
+		tokens = append(tokens, token{variable: name})
+		i += 1 + n
+	}
+	if literal.Len() > 0 {
+		tokens = append(tokens, token{literal: literal.String()})
+	}
+
+	f := &Format{tokens: tokens}
+	if len(f.Variables()) == 0 {
+		return nil, errors.New("the log format has no variables")
+	}
+	return f, nil
+}
+
+// variableName returns the name of the variable at the start of s, given as name or {name}, and its length in s.
+func variableName(s string) (string, int) {
+	if strings.HasPrefix(s, "{") {
+		end := strings.IndexByte(s, '}')
+		if end < 0 || !isVariableName(s[1:end]) {
+			return "", 0
+		}
+		return s[1:end], end + 1
+	}
+	n := 0
+	for n < len(s) && isVariableChar(s[n]) {
+		n++
+	}
+	return s[:n], n
+}
+
+func isVariableName(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isVariableChar(s[i]) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isVariableChar(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// Variables returns the names of the variables of the format in their order. The variables of a JSON format are not
+// known before the lines are parsed, only the sorted variables of the mapped fields are returned.
+func (f *Format) Variables() []string {
+	var variables []string
+	if f.json {
+		for _, variable := range f.fields {
+			variables = append(variables, variable)
+		}
+		sort.Strings(variables)
+		return variables
+	}
+	for _, t := range f.tokens {
+		if t.variable != "" {
+			variables = append(variables, t.variable)
+		}
+	}
+	return variables
+}
+
+// Parse splits a log line into the values of the variables. The value of a variable ends right before the first
+// occurrence of the literal text following it, nginx escapes the quotes in the values of the variables. The values of
+// the upstream variables may contain the separators of several tries.
+func (f *Format) Parse(line string) (Entry, error) {
+	if f.json {
+		return f.parseJSON(line)
+	}
+	entry := make(Entry, len(f.tokens))
+	rest := line
+	for i, t := range f.tokens {
+		if t.literal != "" {
+			if !strings.HasPrefix(rest, t.literal) {
+				return nil, fmt.Errorf("expected %q at %q", t.literal, rest)
+			}
+			rest = rest[len(t.literal):]
+			continue
+		}
+		if i == len(f.tokens)-1 {
+			entry[t.variable] = rest
+			rest = ""
+			continue
+		}
+		end := valueEnd(rest, t.variable, f.tokens[i+1].literal)
+		if end < 0 {
+			return nil, fmt.Errorf("expected %q after the value of %v at %q", f.tokens[i+1].literal, t.variable, rest)
+		}
+		entry[t.variable] = rest[:end]
+		rest = rest[end:]
+	}
+	if rest != "" {
+		return nil, fmt.Errorf("unexpected %q at the end of the line", rest)
+	}
+	return entry, nil
+}
+
+// valueEnd returns the index of the literal that ends the value of the variable. nginx joins the values of the upstream
+// variables of several tries with ", " and of internal redirects with " : ", a literal starting with a space is not
+// the end of the value within these separators, e.g. in 10.0.0.1:80, 10.0.0.2:80 for $upstream_addr $status.
+func valueEnd(s string, variable string, literal string) int {
+	if !strings.HasPrefix(variable, "upstream_") || !strings.HasPrefix(literal, " ") {
+		return strings.Index(s, literal)
+	}
+	for offset := 0; ; {
+		i := strings.Index(s[offset:], literal)
+		if i < 0 {
+			return -1
+		}
+		i += offset
+		if !strings.HasSuffix(s[:i], ",") && !strings.HasSuffix(s[:i], " :") && !strings.HasPrefix(s[i:], " : ") {
+			return i
+		}
+		offset = i + 1
+	}
+}
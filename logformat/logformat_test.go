@@ -0,0 +1,156 @@
+package logformat
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		format        string
+		wantVariables []string
+		wantErr       bool
+	}{
+		{
+			name:          "combined",
+			format:        Combined,
+			wantVariables: []string{"remote_addr", "remote_user", "time_local", "request", "status", "body_bytes_sent", "http_referer", "http_user_agent"},
+		},
+		{
+			name:          "braces",
+			format:        `${host}:${server_port} $request_time`,
+			wantVariables: []string{"host", "server_port", "request_time"},
+		},
+		{
+			name:    "adjacent variables",
+			format:  `$host$request_uri`,
+			wantErr: true,
+		},
+		{
+			name:    "unclosed brace",
+			format:  `${host $status`,
+			wantErr: true,
+		},
+		{
+			name:    "no variables",
+			format:  `static`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := f.Variables(); !reflect.DeepEqual(got, tt.wantVariables) {
+				t.Errorf("Variables() = %v, want %v", got, tt.wantVariables)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		format  string
+		line    string
+		want    Entry
+		wantErr bool
+	}{
+		{
+			name:   "combined",
+			format: Combined,
+			line:   `127.0.0.1 - - [21/Oct/2015:16:29:41 +0000] "GET /index.html HTTP/1.1" 200 612 "-" "curl/8.1.2"`,
+			want: Entry{
+				"remote_addr": "127.0.0.1", "remote_user": "-", "time_local": "21/Oct/2015:16:29:41 +0000",
+				"request": "GET /index.html HTTP/1.1", "status": "200", "body_bytes_sent": "612",
+				"http_referer": "-", "http_user_agent": "curl/8.1.2",
+			},
+		},
+		{
+			name:   "escaped quotes and trailing variable",
+			format: `"$http_user_agent" $host $request_time`,
+			line:   `"agent \x22quoted\x22 (x11)" example.com 0.012`,
+			want:   Entry{"http_user_agent": `agent \x22quoted\x22 (x11)`, "host": "example.com", "request_time": "0.012"},
+		},
+		{
+			name:   "upstream tries",
+			format: `$upstream_addr $upstream_status $request_time`,
+			line:   `10.0.0.1:80, 10.0.0.2:80 : 10.0.0.3:80 502, 504 : 200 0.031`,
+			want:   Entry{"upstream_addr": "10.0.0.1:80, 10.0.0.2:80 : 10.0.0.3:80", "upstream_status": "502, 504 : 200", "request_time": "0.031"},
+		},
+		{
+			name:   "json preset",
+			format: "json",
+			line: `{"time_local":"21/Oct/2015:16:29:41 +0000","remote_addr":"127.0.0.1","request":"GET / HTTP/1.1","status":"200",` +
+				`"body_bytes_sent":"612","request_length":"80","request_time":"0.012","server_name":"example.com",` +
+				`"http_referer":"","http_user_agent":"agent \"x\", y","upstream_addr":"10.0.0.1:80","upstream_status":"200"}`,
+			want: Entry{
+				"time_local": "21/Oct/2015:16:29:41 +0000", "remote_addr": "127.0.0.1", "request": "GET / HTTP/1.1",
+				"status": "200", "body_bytes_sent": "612", "request_length": "80", "request_time": "0.012",
+				"server_name": "example.com", "http_referer": "", "http_user_agent": `agent "x", y`,
+				"upstream_addr": "10.0.0.1:80", "upstream_status": "200",
+			},
+		},
+		{
+			name:   "kube-ingress preset",
+			format: "kube-ingress",
+			line: `10.0.0.9 - - [21/Oct/2015:16:29:41 +0000] "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2" 80 0.031 ` +
+				`[default-web-80] [] 10.0.0.1:80, 10.0.0.2:80 0, 612 0.010, 0.021 502, 200 3f2a`,
+			want: Entry{
+				"remote_addr": "10.0.0.9", "remote_user": "-", "time_local": "21/Oct/2015:16:29:41 +0000",
+				"request": "GET / HTTP/1.1", "status": "200", "body_bytes_sent": "612", "http_referer": "-",
+				"http_user_agent": "curl/8.1.2", "request_length": "80", "request_time": "0.031",
+				"proxy_upstream_name": "default-web-80", "proxy_alternative_upstream_name": "",
+				"upstream_addr": "10.0.0.1:80, 10.0.0.2:80", "upstream_response_length": "0, 612",
+				"upstream_response_time": "0.010, 0.021", "upstream_status": "502, 200", "req_id": "3f2a",
+			},
+		},
+		{
+			name:    "missing literal",
+			format:  Combined,
+			line:    `127.0.0.1 - - 21/Oct/2015:16:29:41 +0000 "GET / HTTP/1.1" 200 612 "-" "curl/8.1.2"`,
+			wantErr: true,
+		},
+		{
+			name:    "trailing text",
+			format:  `[$status]`,
+			line:    `[200] extra`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.format)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			got, err := f.Parse(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, want error %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) && !tt.wantErr {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPresets(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range Presets() {
+		if _, err := New(name); err != nil {
+			t.Errorf("New(%q) error = %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package syslog receives the log lines nginx sends with the syslog: prefix of the access_log and error_log directives.
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// maxMessageSize is the size of the largest message read, nginx sends messages of up to 4 KiB.
+const maxMessageSize = 64 * 1024
+
+// Listener receives syslog messages over UDP or TCP and passes the log line of every message to a handler.
+type Listener struct {
+	network    string
+	packetConn net.PacketConn
+	listener   net.Listener
+	logger     log.Logger
+}
+
+// Listen starts listening on address given as udp://host:port or tcp://host:port. nginx sends the messages over UDP,
+// TCP is supported for syslog relays, with the messages framed by newlines or octet counting.
+func Listen(address string, logger log.Logger) (*Listener, error) {
+	network, hostport, ok := strings.Cut(address, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid syslog address %q, expected udp://host:port or tcp://host:port", address)
+	}
+
+	l := &Listener{network: network, logger: logger}
+	var err error
+	switch network {
+	case "udp":
+		l.packetConn, err = net.ListenPacket("udp", hostport)
+	case "tcp":
+		l.listener, err = net.Listen("tcp", hostport)
+	default:
+		return nil, fmt.Errorf("unsupported syslog network %q, expected udp or tcp", network)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %v: %w", address, err)
+	}
+	return l, nil
+}
+
+// Addr returns the address the listener listens on.
+func (l *Listener) Addr() net.Addr {
+	if l.packetConn != nil {
+		return l.packetConn.LocalAddr()
+	}
+	return l.listener.Addr()
+}
+
+// Run receives messages until ctx is done. The handler is called concurrently for the messages of TCP connections.
+func (l *Listener) Run(ctx context.Context, handle func(line string)) {
+	go func() {
+		<-ctx.Done()
+		if l.packetConn != nil {
+			l.packetConn.Close()
+		} else {
+			l.listener.Close()
+		}
+	}()
+
+	if l.packetConn != nil {
+		l.runUDP(handle)
+		return
+	}
+	l.runTCP(handle)
+}
+
+func (l *Listener) runUDP(handle func(line string)) {
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := l.packetConn.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			level.Warn(l.logger).Log("msg", "Error receiving syslog message", "error", err.Error())
+			continue
+		}
+		l.handleMessage(string(buf[:n]), handle)
+	}
+}
+
+func (l *Listener) runTCP(handle func(line string)) {
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			level.Warn(l.logger).Log("msg", "Error accepting syslog connection", "error", err.Error())
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			l.readMessages(conn, handle)
+		}()
+	}
+}
+
+// readMessages reads the messages of a TCP connection until it is closed.
+func (l *Listener) readMessages(r io.Reader, handle func(line string)) {
+	reader := bufio.NewReaderSize(r, maxMessageSize)
+	for {
+		message, err := readFramedMessage(reader)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				level.Warn(l.logger).Log("msg", "Error reading syslog connection", "error", err.Error())
+			}
+			return
+		}
+		l.handleMessage(message, handle)
+	}
+}
+
+// readFramedMessage reads a message framed by octet counting, i.e. prefixed with its length, or terminated by a newline.
+func readFramedMessage(reader *bufio.Reader) (string, error) {
+	first, err := reader.Peek(1)
+	if err != nil {
+		return "", err
+	}
+	if first[0] >= '0' && first[0] <= '9' {
+		length, err := reader.ReadString(' ')
+		if err != nil {
+			return "", err
+		}
+		n, err := strconv.Atoi(strings.TrimSuffix(length, " "))
+		if err != nil || n > maxMessageSize {
+			return "", fmt.Errorf("invalid message length %q", length)
+		}
+		message := make([]byte, n)
+		if _, err := io.ReadFull(reader, message); err != nil {
+			return "", err
+		}
+		return string(message), nil
+	}
+	message, err := reader.ReadString('\n')
+	if err != nil && message == "" {
+		return "", err
+	}
+	return strings.TrimRight(message, "\r\n"), nil
+}
+
+func (l *Listener) handleMessage(message string, handle func(line string)) {
+	line, ok := ParseMessage(message)
+	if !ok {
+		level.Debug(l.logger).Log("msg", "Error parsing syslog message", "message", message)
+		return
+	}
+	handle(line)
+}
+
+// ParseMessage returns the log line of a message in the BSD syslog format nginx sends, e.g.
+// <190>Oct 14 17:53:38 hostname nginx: 127.0.0.1 - - [14/Oct/2026:17:53:38 +0000] "GET / HTTP/1.1" 200 612.
+// The hostname is omitted with the nohostname parameter of the directives.
+func ParseMessage(message string) (string, bool) {
+	if !strings.HasPrefix(message, "<") {
+		return "", false
+	}
+	end := strings.IndexByte(message, '>')
+	if end < 0 {
+		return "", false
+	}
+	if _, err := strconv.Atoi(message[1:end]); err != nil {
+		return "", false
+	}
+	// the timestamp is Mmm dd hh:mm:ss followed by a space, the day is padded with a space
+	header := message[end+1:]
+	if len(header) < 16 || header[15] != ' ' {
+		return "", false
+	}
+	// the tag, nginx by default, ends with a colon and a space
+	tag := strings.Index(header[16:], ": ")
+	if tag < 0 {
+		return "", false
+	}
+	return strings.TrimRight(header[16+tag+2:], "\r\n"), true
+}
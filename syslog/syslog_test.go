@@ -0,0 +1,124 @@
+package syslog
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestParseMessage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		message  string
+		wantLine string
+		wantOk   bool
+	}{
+		{
+			name:     "message with hostname",
+			message:  `<190>Oct 14 17:53:38 web-1 nginx: 127.0.0.1 - - [14/Oct/2026:17:53:38 +0000] "GET / HTTP/1.1" 200 612`,
+			wantLine: `127.0.0.1 - - [14/Oct/2026:17:53:38 +0000] "GET / HTTP/1.1" 200 612`,
+			wantOk:   true,
+		},
+		{
+			name:     "message without hostname and padded day",
+			message:  "<190>Oct  4 07:03:08 access: GET / 200\n",
+			wantLine: "GET / 200",
+			wantOk:   true,
+		},
+		{
+			name:    "no priority",
+			message: "Oct 14 17:53:38 web-1 nginx: GET / 200",
+			wantOk:  false,
+		},
+		{
+			name:    "no tag",
+			message: "<190>Oct 14 17:53:38 GET / 200",
+			wantOk:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line, ok := ParseMessage(tt.message)
+			if ok != tt.wantOk {
+				t.Fatalf("ParseMessage() ok = %v, want %v", ok, tt.wantOk)
+			}
+			if line != tt.wantLine {
+				t.Errorf("ParseMessage() = %q, want %q", line, tt.wantLine)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		network  string
+		messages string
+		want     []string
+	}{
+		{
+			name:     "udp",
+			network:  "udp",
+			messages: "<190>Oct 14 17:53:38 web-1 nginx: GET / 200",
+			want:     []string{"GET / 200"},
+		},
+		{
+			name:     "tcp",
+			network:  "tcp",
+			messages: "<190>Oct 14 17:53:38 web-1 nginx: GET / 200\n43 <190>Oct 14 17:53:38 web-1 nginx: GET /\n302",
+			want:     []string{"GET / 200", "GET /\n302"},
+		},
+	}
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			listener, err := Listen(test.network+"://127.0.0.1:0", log.NewNopLogger())
+			if err != nil {
+				t.Fatalf("Listen() error = %v", err)
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			lines := make(chan string, len(test.want))
+			go listener.Run(ctx, func(line string) { lines <- line })
+
+			conn, err := net.Dial(test.network, listener.Addr().String())
+			if err != nil {
+				t.Fatalf("Dial() error = %v", err)
+			}
+			defer conn.Close()
+			if _, err := conn.Write([]byte(test.messages)); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+
+			for _, want := range test.want {
+				select {
+				case line := <-lines:
+					if line != want {
+						t.Errorf("line = %q, want %q", line, want)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatalf("no line received, want %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestListenInvalidAddress(t *testing.T) {
+	t.Parallel()
+
+	for _, address := range []string{"127.0.0.1:514", "unix:///var/run/syslog.sock"} {
+		if _, err := Listen(address, log.NewNopLogger()); err == nil {
+			t.Errorf("Listen(%q) error = nil, want an error", address)
+		}
+	}
+}
@@ -0,0 +1,174 @@
+package tail
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// Glob follows all the log files that match a pattern, e.g. /var/log/nginx/*.access.log, with a Tailer per file.
+// The pattern is checked again every interval: the files created later are read from the start, and the files that
+// no longer match are dropped after the rest of them is read.
+type Glob struct {
+	pattern  string
+	interval time.Duration
+	logger   log.Logger
+
+	mu      sync.Mutex
+	tailers map[string]*Tailer
+}
+
+// NewGlob creates a Glob that checks the files that match pattern for new lines every interval. The pattern has the
+// syntax of filepath.Match.
+func NewGlob(pattern string, interval time.Duration, logger log.Logger) (*Glob, error) {
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("invalid log file pattern %q: %w", pattern, err)
+	}
+	return &Glob{
+		pattern:  pattern,
+		interval: interval,
+		logger:   logger,
+		tailers:  make(map[string]*Tailer),
+	}, nil
+}
+
+// IsPattern reports whether path has any of the special characters of filepath.Match.
+func IsPattern(path string) bool {
+	return strings.ContainsAny(path, `*?[\`)
+}
+
+// Name returns the part of the file name at path that is matched by the special characters of the pattern, e.g.
+// example.com for /var/log/nginx/example.com.access.log and the pattern /var/log/nginx/*.access.log.
+func (g *Glob) Name(path string) string {
+	base, pattern := filepath.Base(path), filepath.Base(g.pattern)
+	first, last := strings.IndexAny(pattern, `*?[\`), strings.LastIndexAny(pattern, `*?]`)
+	if first < 0 {
+		return ""
+	}
+	prefix, suffix := pattern[:first], pattern[last+1:]
+	if len(base) < len(prefix)+len(suffix) {
+		return ""
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(base, prefix), suffix)
+}
+
+// FileStats returns the counters of the followed files by their paths. It is safe to call while Run is running.
+func (g *Glob) FileStats() map[string]Stats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats := make(map[string]Stats, len(g.tailers))
+	for path, tailer := range g.tailers {
+		stats[path] = tailer.Stats()
+	}
+	return stats
+}
+
+// Run follows the matching files until ctx is done. Lines already in the files when Run starts are skipped. The path
+// of the file is passed to handle with every line.
+func (g *Glob) Run(ctx context.Context, handle func(path string, line string)) {
+	g.update(true)
+	defer g.closeAll()
+
+	ticker := time.NewTicker(g.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			g.poll(handle)
+		}
+	}
+}
+
+// poll reads the lines appended to every file since the last poll, then checks the pattern again.
+func (g *Glob) poll(handle func(path string, line string)) {
+	g.mu.Lock()
+	tailers := make(map[string]*Tailer, len(g.tailers))
+	for path, tailer := range g.tailers {
+		tailers[path] = tailer
+	}
+	g.mu.Unlock()
+
+	g.pollTailers(tailers, handle)
+	g.update(false)
+	g.pollTailers(g.added(tailers), handle)
+}
+
+func (g *Glob) pollTailers(tailers map[string]*Tailer, handle func(path string, line string)) {
+	for path, tailer := range tailers {
+		path := path
+		if err := tailer.poll(func(line string) { handle(path, line) }); err != nil {
+			level.Warn(g.logger).Log("msg", "Error reading log file", "path", path, "error", err.Error())
+		}
+	}
+}
+
+// update adds a Tailer for every new matching file and drops the Tailers of the files that no longer match. The files
+// found when the Glob starts are followed from their end.
+func (g *Glob) update(start bool) {
+	paths, err := filepath.Glob(g.pattern)
+	if err != nil {
+		level.Warn(g.logger).Log("msg", "Error matching log files", "pattern", g.pattern, "error", err.Error())
+		return
+	}
+	matches := make(map[string]bool, len(paths))
+	for _, path := range paths {
+		matches[path] = true
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for path, tailer := range g.tailers {
+		if !matches[path] {
+			tailer.close()
+			delete(g.tailers, path)
+			level.Debug(g.logger).Log("msg", "Stopped following the log file", "path", path)
+		}
+	}
+	for path := range matches {
+		if _, ok := g.tailers[path]; ok {
+			continue
+		}
+		tailer := NewTailer(path, g.interval, g.logger)
+		if start {
+			if err := tailer.open(true); err != nil {
+				level.Warn(g.logger).Log("msg", "Error opening log file", "path", path, "error", err.Error())
+			}
+		}
+		g.tailers[path] = tailer
+		level.Debug(g.logger).Log("msg", "Started following the log file", "path", path)
+	}
+}
+
+// added returns the Tailers that are not in previous.
+func (g *Glob) added(previous map[string]*Tailer) map[string]*Tailer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	added := make(map[string]*Tailer)
+	for path, tailer := range g.tailers {
+		if _, ok := previous[path]; !ok {
+			added[path] = tailer
+		}
+	}
+	return added
+}
+
+func (g *Glob) closeAll() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, tailer := range g.tailers {
+		tailer.close()
+	}
+}
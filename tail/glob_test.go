@@ -0,0 +1,82 @@
+package tail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestGlobPoll(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	appendFile(t, filepath.Join(dir, "a.access.log"), "existing line that must be skipped\n")
+	appendFile(t, filepath.Join(dir, "error.log"), "not matched\n")
+
+	glob, err := NewGlob(filepath.Join(dir, "*.access.log"), time.Second, log.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewGlob() error = %v", err)
+	}
+	glob.update(true)
+	defer glob.closeAll()
+
+	var got []string
+	handle := func(path string, line string) { got = append(got, glob.Name(path)+": "+line) }
+
+	appendFile(t, filepath.Join(dir, "a.access.log"), "one\n")
+	appendFile(t, filepath.Join(dir, "b.access.log"), "two\n")
+	appendFile(t, filepath.Join(dir, "error.log"), "not matched\n")
+	glob.poll(handle)
+
+	if err := os.Remove(filepath.Join(dir, "a.access.log")); err != nil {
+		t.Fatal(err)
+	}
+	appendFile(t, filepath.Join(dir, "b.access.log"), "three\n")
+	glob.poll(handle)
+
+	sort.Strings(got)
+	want := []string{"a: one", "b: three", "b: two"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("poll() lines = %q, want %q", got, want)
+	}
+	if _, ok := glob.FileStats()[filepath.Join(dir, "a.access.log")]; ok {
+		t.Errorf("FileStats() has the removed file")
+	}
+}
+
+func TestGlobName(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern string
+		path    string
+		want    string
+	}{
+		{pattern: "/var/log/nginx/*.access.log", path: "/var/log/nginx/example.com.access.log", want: "example.com"},
+		{pattern: "/var/log/nginx/access-*.log", path: "/var/log/nginx/access-example.com.log", want: "example.com"},
+		{pattern: "/var/log/*/access.log", path: "/var/log/example.com/access.log", want: ""},
+		{pattern: "/var/log/nginx/access.log", path: "/var/log/nginx/access.log", want: ""},
+	}
+	for _, tt := range tests {
+		glob, err := NewGlob(tt.pattern, time.Second, log.NewNopLogger())
+		if err != nil {
+			t.Fatalf("NewGlob() error = %v", err)
+		}
+		if got := glob.Name(tt.path); got != tt.want {
+			t.Errorf("Name(%q) with %q = %q, want %q", tt.path, tt.pattern, got, tt.want)
+		}
+	}
+}
+
+func TestNewGlobInvalidPattern(t *testing.T) {
+	t.Parallel()
+
+	if _, err := NewGlob("/var/log/nginx/[.log", time.Second, log.NewNopLogger()); err == nil {
+		t.Error("NewGlob() error = nil, want an error")
+	}
+}
@@ -0,0 +1,220 @@
+package tail
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+)
+
+// maxLineLength is the maximum length of a line. The longer lines are dropped, so that a file without newlines
+// cannot fill the memory.
+const maxLineLength = 1 << 20
+
+// Tailer follows a log file and passes every line appended to it to a handler.
+// A truncated file is read again from the start, a replaced (rotated) file is reopened.
+type Tailer struct {
+	path     string
+	interval time.Duration
+	logger   log.Logger
+
+	file   *os.File
+	reader *bufio.Reader
+	offset int64
+	// partial is the incomplete last line, skipping is set while the rest of a line longer than maxLineLength is read
+	partial  []byte
+	skipping bool
+
+	reopens     atomic.Uint64
+	truncations atomic.Uint64
+	lostLines   atomic.Uint64
+}
+
+// Stats are the counters of a Tailer since it was created.
+type Stats struct {
+	// Reopens is the number of times a replaced file was reopened, e.g. after logrotate renamed it.
+	Reopens uint64
+	// Truncations is the number of times the file was truncated, e.g. by the copytruncate option of logrotate.
+	Truncations uint64
+	// LostLines is the number of incomplete lines dropped when the file was replaced or truncated and of the lines
+	// dropped because they were longer than 1 MiB. The lines written right before a truncation, after the last check
+	// of the file, are lost without being counted.
+	LostLines uint64
+}
+
+// NewTailer creates a Tailer that checks the file at path for new lines every interval.
+func NewTailer(path string, interval time.Duration, logger log.Logger) *Tailer {
+	return &Tailer{
+		path:     path,
+		interval: interval,
+		logger:   logger,
+	}
+}
+
+// Path returns the path of the followed file.
+func (t *Tailer) Path() string {
+	return t.path
+}
+
+// Stats returns the counters of the Tailer. It is safe to call while Run is running.
+func (t *Tailer) Stats() Stats {
+	return Stats{
+		Reopens:     t.reopens.Load(),
+		Truncations: t.truncations.Load(),
+		LostLines:   t.lostLines.Load(),
+	}
+}
+
+// FileStats returns the counters of the Tailer by the path of the file, like Glob.FileStats.
+func (t *Tailer) FileStats() map[string]Stats {
+	return map[string]Stats{t.path: t.Stats()}
+}
+
+// Run follows the file until ctx is done. Lines already in the file when Run starts are skipped.
+func (t *Tailer) Run(ctx context.Context, handle func(line string)) {
+	if err := t.open(true); err != nil {
+		level.Warn(t.logger).Log("msg", "Error opening log file", "path", t.path, "error", err.Error())
+	}
+	defer t.close()
+
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.poll(handle); err != nil {
+				level.Warn(t.logger).Log("msg", "Error reading log file", "path", t.path, "error", err.Error())
+			}
+		}
+	}
+}
+
+// poll reads the lines appended since the last poll.
+func (t *Tailer) poll(handle func(line string)) error {
+	if t.file == nil {
+		if err := t.open(false); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+	}
+
+	info, err := os.Stat(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			// the file is being rotated, finish the old one and wait for the new one
+			return t.read(handle)
+		}
+		return err
+	}
+
+	current, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(info, current) {
+		if err := t.read(handle); err != nil {
+			return err
+		}
+		t.close()
+		t.dropPartial()
+		if err := t.open(false); err != nil {
+			return err
+		}
+		t.reopens.Add(1)
+		level.Debug(t.logger).Log("msg", "Reopened the replaced log file", "path", t.path)
+	} else if info.Size() < t.offset {
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		t.reader.Reset(t.file)
+		t.offset = 0
+		t.dropPartial()
+		t.truncations.Add(1)
+		level.Debug(t.logger).Log("msg", "Reading the truncated log file from the start", "path", t.path)
+	}
+
+	return t.read(handle)
+}
+
+func (t *Tailer) read(handle func(line string)) error {
+	if t.file == nil {
+		return nil
+	}
+	for {
+		chunk, err := t.reader.ReadSlice('\n')
+		t.offset += int64(len(chunk))
+		if !t.skipping {
+			if len(t.partial)+len(chunk) > maxLineLength {
+				t.partial = t.partial[:0]
+				t.skipping = true
+				t.lostLines.Add(1)
+			} else {
+				t.partial = append(t.partial, chunk...)
+			}
+		}
+		switch {
+		case err == nil:
+			if !t.skipping {
+				handle(strings.TrimRight(string(t.partial), "\r\n"))
+			}
+			t.partial = t.partial[:0]
+			t.skipping = false
+		case errors.Is(err, bufio.ErrBufferFull):
+			// the line is longer than the buffer, read the rest of it
+		case errors.Is(err, io.EOF):
+			// keep an incomplete last line until the rest of it is written
+			return nil
+		default:
+			return err
+		}
+	}
+}
+
+// dropPartial drops the incomplete last line, the rest of it is never written to the file.
+func (t *Tailer) dropPartial() {
+	if len(t.partial) > 0 {
+		t.lostLines.Add(1)
+	}
+	t.partial = t.partial[:0]
+	t.skipping = false
+}
+
+func (t *Tailer) open(seekEnd bool) error {
+	file, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	var offset int64
+	if seekEnd {
+		offset, err = file.Seek(0, io.SeekEnd)
+		if err != nil {
+			file.Close()
+			return err
+		}
+	}
+	t.file = file
+	t.reader = bufio.NewReader(file)
+	t.offset = offset
+	t.partial = t.partial[:0]
+	t.skipping = false
+	return nil
+}
+
+func (t *Tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
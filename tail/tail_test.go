@@ -0,0 +1,136 @@
+package tail
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+)
+
+func TestTailerPoll(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		steps     func(t *testing.T, path string)
+		want      []string
+		wantStats Stats
+	}{
+		{
+			name: "appended lines",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "one\ntwo\n")
+			},
+			want: []string{"one", "two"},
+		},
+		{
+			name: "incomplete line",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "one\ntw")
+			},
+			want: []string{"one"},
+		},
+		{
+			name: "truncated file",
+			steps: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("new\n"), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want:      []string{"new"},
+			wantStats: Stats{Truncations: 1},
+		},
+		{
+			name: "rotated file",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "old\n")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, path, "new\n")
+			},
+			want:      []string{"old", "new"},
+			wantStats: Stats{Reopens: 1},
+		},
+		{
+			name: "rotated file with an incomplete line",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "old\nol")
+				if err := os.Rename(path, path+".1"); err != nil {
+					t.Fatal(err)
+				}
+				appendFile(t, path, "new\n")
+			},
+			want:      []string{"old", "new"},
+			wantStats: Stats{Reopens: 1, LostLines: 1},
+		},
+		{
+			name: "line longer than the limit",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "one\n"+strings.Repeat("x", maxLineLength)+"\ntwo\n")
+			},
+			want:      []string{"one", "two"},
+			wantStats: Stats{LostLines: 1},
+		},
+		{
+			name: "incomplete line longer than the limit",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, "one\n"+strings.Repeat("x", maxLineLength+1))
+			},
+			want:      []string{"one"},
+			wantStats: Stats{LostLines: 1},
+		},
+		{
+			name: "line of the limit",
+			steps: func(t *testing.T, path string) {
+				appendFile(t, path, strings.Repeat("x", maxLineLength-1)+"\n")
+			},
+			want: []string{strings.Repeat("x", maxLineLength-1)},
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "access.log")
+			appendFile(t, path, "existing line that must be skipped\n")
+
+			tailer := NewTailer(path, time.Second, log.NewNopLogger())
+			if err := tailer.open(true); err != nil {
+				t.Fatal(err)
+			}
+			defer tailer.close()
+
+			tt.steps(t, path)
+
+			var got []string
+			if err := tailer.poll(func(line string) { got = append(got, line) }); err != nil {
+				t.Fatalf("poll() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("poll() lines = %q, want %q", got, tt.want)
+			}
+			if stats := tailer.Stats(); stats != tt.wantStats {
+				t.Errorf("Stats() = %+v, want %+v", stats, tt.wantStats)
+			}
+		})
+	}
+}
+
+func appendFile(t *testing.T, path string, data string) {
+	t.Helper()
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if _, err := f.WriteString(data); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unitSocketDiscovery registers a collector for every NGINX Unit control socket matching a glob pattern
+// and unregisters the collectors of the sockets that disappear.
+type unitSocketDiscovery struct {
+	pattern         string
+	interval        time.Duration
+	createCollector func(scrapeURI string) (prometheus.Collector, error)
+	registerer      prometheus.Registerer
+	collectors      map[string]prometheus.Collector
+	logger          log.Logger
+}
+
+func newUnitSocketDiscovery(pattern string, interval time.Duration, registerer prometheus.Registerer,
+	createCollector func(scrapeURI string) (prometheus.Collector, error), logger log.Logger,
+) (*unitSocketDiscovery, error) {
+	// check the pattern once, Glob only fails for a malformed pattern
+	if _, err := filepath.Glob(pattern); err != nil {
+		return nil, err
+	}
+	if interval <= 0 {
+		return nil, errors.New("rescan interval must be positive")
+	}
+	return &unitSocketDiscovery{
+		pattern:         pattern,
+		interval:        interval,
+		createCollector: createCollector,
+		registerer:      registerer,
+		collectors:      make(map[string]prometheus.Collector),
+		logger:          logger,
+	}, nil
+}
+
+// run scans for the sockets until ctx is done.
+func (d *unitSocketDiscovery) run(ctx context.Context) {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.scan()
+		}
+	}
+}
+
+// scan registers the collectors of the new sockets and unregisters the collectors of the removed ones.
+// The sockets that cannot be scraped yet are tried again on the next scan.
+func (d *unitSocketDiscovery) scan() {
+	found, err := globUnitSockets(d.pattern)
+	if err != nil {
+		level.Error(d.logger).Log("msg", "Error discovering NGINX Unit sockets", "pattern", d.pattern, "error", err.Error())
+		return
+	}
+	added, removed := diffScrapeURIs(d.collectors, found)
+
+	for _, uri := range removed {
+		d.registerer.Unregister(d.collectors[uri])
+		delete(d.collectors, uri)
+		level.Info(d.logger).Log("msg", "NGINX Unit socket removed", "uri", uri)
+	}
+	for _, uri := range added {
+		c, err := d.createCollector(uri)
+		if err != nil {
+			level.Warn(d.logger).Log("msg", "Could not create Nginx Client", "uri", uri, "error", err.Error())
+			continue
+		}
+		if err := d.registerer.Register(c); err != nil {
+			level.Warn(d.logger).Log("msg", "Could not register NGINX Unit collector", "uri", uri, "error", err.Error())
+			continue
+		}
+		d.collectors[uri] = c
+		level.Info(d.logger).Log("msg", "NGINX Unit socket discovered", "uri", uri)
+	}
+}
+
+// globUnitSockets returns the scrape URIs of the sockets matching the pattern.
+func globUnitSockets(pattern string) ([]string, error) {
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	uris := make([]string, 0, len(paths))
+	for _, path := range paths {
+		uris = append(uris, "unix:"+path)
+	}
+	return uris, nil
+}
+
+// diffScrapeURIs returns the sorted found URIs that are not known and the known URIs that are not found.
+func diffScrapeURIs(known map[string]prometheus.Collector, found []string) ([]string, []string) {
+	var added, removed []string
+	foundSet := make(map[string]bool, len(found))
+	for _, uri := range found {
+		foundSet[uri] = true
+		if _, ok := known[uri]; !ok {
+			added = append(added, uri)
+		}
+	}
+	for uri := range known {
+		if !foundSet[uri] {
+			removed = append(removed, uri)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return added, removed
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDiffScrapeURIs(t *testing.T) {
+	t.Parallel()
+
+	collector := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test"})
+	tests := []struct {
+		name        string
+		known       map[string]prometheus.Collector
+		found       []string
+		wantAdded   []string
+		wantRemoved []string
+	}{
+		{
+			"New sockets",
+			map[string]prometheus.Collector{},
+			[]string{"unix:/var/run/unit/b.sock", "unix:/var/run/unit/a.sock"},
+			[]string{"unix:/var/run/unit/a.sock", "unix:/var/run/unit/b.sock"},
+			nil,
+		},
+		{
+			"Removed socket",
+			map[string]prometheus.Collector{"unix:/var/run/unit/a.sock": collector, "unix:/var/run/unit/b.sock": collector},
+			[]string{"unix:/var/run/unit/b.sock"},
+			nil,
+			[]string{"unix:/var/run/unit/a.sock"},
+		},
+		{
+			"Replaced socket",
+			map[string]prometheus.Collector{"unix:/var/run/unit/a.sock": collector},
+			[]string{"unix:/var/run/unit/c.sock"},
+			[]string{"unix:/var/run/unit/c.sock"},
+			[]string{"unix:/var/run/unit/a.sock"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, removed := diffScrapeURIs(tt.known, tt.found)
+			if !reflect.DeepEqual(added, tt.wantAdded) {
+				t.Errorf("diffScrapeURIs() added = %v, want %v", added, tt.wantAdded)
+			}
+			if !reflect.DeepEqual(removed, tt.wantRemoved) {
+				t.Errorf("diffScrapeURIs() removed = %v, want %v", removed, tt.wantRemoved)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
+)
+
+// pingUnit checks that the status of the NGINX Unit instance can be fetched and parsed
+// and writes a summary of the status to w.
+func pingUnit(ctx context.Context, w io.Writer, uri string, unitClient *unitclient.NginxClient) error {
+	status, err := unitClient.GetStatusWithContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get the status: %w", err)
+	}
+
+	version := status.Version
+	if version == "" {
+		version = "unknown"
+	}
+	applications := make([]string, 0, len(status.Applications))
+	for name := range status.Applications {
+		applications = append(applications, name)
+	}
+	sort.Strings(applications)
+
+	fmt.Fprintf(w, "%v: OK\n", uri)
+	fmt.Fprintf(w, "  version: %v\n", version)
+	fmt.Fprintf(w, "  connections: accepted %v, active %v, idle %v, closed %v\n",
+		status.Connections.Accepted, status.Connections.Active, status.Connections.Idle, status.Connections.Closed)
+	fmt.Fprintf(w, "  requests: %v\n", status.Requests.Total)
+	fmt.Fprintf(w, "  applications: %v [%v]\n", len(applications), strings.Join(applications, ", "))
+	return nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	unitclient "github.com/nginxinc/nginx-prometheus-exporter/client/unit"
+	"github.com/nginxinc/nginx-prometheus-exporter/client/unit/unittest"
+)
+
+func TestPingUnit(t *testing.T) {
+	t.Parallel()
+
+	server := unittest.NewServer()
+	defer server.Close()
+	server.SetStatus(unittest.NewStatus().WithConnections(10, 2, 1, 7).WithRequests(42).
+		WithApplication("shop", 1, 0, 0, 0, 2).WithApplication("blogs", 1, 0, 0, 0, 40))
+
+	unitClient, err := unitclient.NewNginxClient(server.Client(), server.StatusURL())
+	if err != nil {
+		t.Fatalf("NewNginxClient() error = %v", err)
+	}
+
+	var out bytes.Buffer
+	if err := pingUnit(context.Background(), &out, server.StatusURL(), unitClient); err != nil {
+		t.Fatalf("pingUnit() error = %v", err)
+	}
+	for _, want := range []string{
+		"version: 1.31.0",
+		"connections: accepted 10, active 2, idle 1, closed 7",
+		"requests: 42",
+		"applications: 2 [blogs, shop]",
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("pingUnit() output = %q, want it to contain %q", out.String(), want)
+		}
+	}
+
+	server.SetError("/status", http.StatusServiceUnavailable)
+	if err := pingUnit(context.Background(), &out, server.StatusURL(), unitClient); err == nil {
+		t.Error("pingUnit() error = nil, want the status error")
+	}
+}